@@ -0,0 +1,186 @@
+package mudrex
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// AccountManager holds clients for several accounts keyed by name and fans
+// calls out to them concurrently. Each client keeps its own rate limiter, so
+// calls to one account never consume another account's budget.
+type AccountManager struct {
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// NewAccountManager creates an empty account manager
+func NewAccountManager() *AccountManager {
+	return &AccountManager{clients: make(map[string]*Client)}
+}
+
+// NewAccountManagerFromConfig creates a manager with a client for every
+// profile in cfg. Environment overrides are not applied, since they would
+// give every account the same credentials.
+func NewAccountManagerFromConfig(cfg *Config) (*AccountManager, error) {
+	m := NewAccountManager()
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.Profiles[name]
+		if sources := countSecretSources(p); sources == 0 {
+			return nil, fmt.Errorf("profile %q has no API secret", name)
+		} else if sources > 1 {
+			return nil, fmt.Errorf("profile %q sets more than one API secret source", name)
+		}
+		m.clients[name] = p.Client()
+	}
+	return m, nil
+}
+
+// Add registers a client under name
+func (m *AccountManager) Add(name string, client *Client) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.clients[name]; exists {
+		return fmt.Errorf("account %q already exists", name)
+	}
+	m.clients[name] = client
+	return nil
+}
+
+// Remove unregisters and closes the named client
+func (m *AccountManager) Remove(name string) {
+	m.mu.Lock()
+	client := m.clients[name]
+	delete(m.clients, name)
+	m.mu.Unlock()
+
+	if client != nil {
+		client.Close()
+	}
+}
+
+// Get returns the named client
+func (m *AccountManager) Get(name string) (*Client, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	client, ok := m.clients[name]
+	return client, ok
+}
+
+// Names returns the account names in sorted order
+func (m *AccountManager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.clients))
+	for name := range m.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes every client
+func (m *AccountManager) Close() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, client := range m.clients {
+		client.Close()
+	}
+	return nil
+}
+
+// AccountResult is the outcome of a call against one account
+type AccountResult[T any] struct {
+	Account string
+	Value   T
+	Err     error
+}
+
+// AccountResults holds per-account outcomes sorted by account name
+type AccountResults[T any] []AccountResult[T]
+
+// Values returns the successful results keyed by account
+func (r AccountResults[T]) Values() map[string]T {
+	values := make(map[string]T, len(r))
+	for _, result := range r {
+		if result.Err == nil {
+			values[result.Account] = result.Value
+		}
+	}
+	return values
+}
+
+// Errors returns the failures keyed by account
+func (r AccountResults[T]) Errors() map[string]error {
+	errs := make(map[string]error)
+	for _, result := range r {
+		if result.Err != nil {
+			errs[result.Account] = result.Err
+		}
+	}
+	return errs
+}
+
+// Err joins every per-account failure, or returns nil if all succeeded
+func (r AccountResults[T]) Err() error {
+	var errs []error
+	for _, result := range r {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("account %s: %w", result.Account, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ForEachAccount calls fn for every account concurrently and collects the results
+func ForEachAccount[T any](m *AccountManager, fn func(account string, client *Client) (T, error)) AccountResults[T] {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.clients))
+	clients := make([]*Client, 0, len(m.clients))
+	for name, client := range m.clients {
+		names = append(names, name)
+		clients = append(clients, client)
+	}
+	m.mu.RUnlock()
+
+	results := make(AccountResults[T], len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := fn(names[i], clients[i])
+			results[i] = AccountResult[T]{Account: names[i], Value: value, Err: err}
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Account < results[j].Account })
+	return results
+}
+
+// OpenPositions lists open positions on every account
+func (m *AccountManager) OpenPositions() AccountResults[[]Position] {
+	return ForEachAccount(m, func(_ string, c *Client) ([]Position, error) {
+		return c.Positions.ListOpen()
+	})
+}
+
+// OpenOrders lists open orders for an asset on every account
+func (m *AccountManager) OpenOrders(assetID string) AccountResults[[]Order] {
+	return ForEachAccount(m, func(_ string, c *Client) ([]Order, error) {
+		return c.Orders.ListOpen(assetID)
+	})
+}
+
+// FuturesBalances retrieves the futures balance of every account
+func (m *AccountManager) FuturesBalances() AccountResults[*FuturesBalance] {
+	return ForEachAccount(m, func(_ string, c *Client) (*FuturesBalance, error) {
+		return c.Wallet.GetFuturesBalance()
+	})
+}
@@ -0,0 +1,649 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// Exit reasons recorded on trades
+const (
+	ExitSignal      = "SIGNAL"
+	ExitStopLoss    = "STOP_LOSS"
+	ExitTakeProfit  = "TAKE_PROFIT"
+	ExitLiquidation = "LIQUIDATION"
+	ExitEndOfData   = "END_OF_DATA"
+)
+
+// Trade is a closed (or partially closed) simulated position
+type Trade struct {
+	PositionID string           `json:"position_id"`
+	AssetID    string           `json:"asset_id"`
+	Side       mudrex.OrderType `json:"side"`
+	Quantity   float64          `json:"quantity"`
+	Leverage   float64          `json:"leverage"`
+	EntryPrice float64          `json:"entry_price"`
+	ExitPrice  float64          `json:"exit_price"`
+	EntryTime  time.Time        `json:"entry_time"`
+	ExitTime   time.Time        `json:"exit_time"`
+	GrossPnL   float64          `json:"gross_pnl"`
+	Fees       float64          `json:"fees"`
+	NetPnL     float64          `json:"net_pnl"`
+	ExitReason string           `json:"exit_reason"`
+}
+
+// Broker simulates order execution for a single asset using the SDK models.
+// Margin is isolated: each position locks quantity*entry/leverage.
+type Broker struct {
+	mu       sync.Mutex
+	assetID  string
+	makerFee float64
+	takerFee float64
+
+	balance float64
+	candle  mudrex.Candle
+	seq     int
+
+	position *simPosition
+	pending  []*simOrder
+	orders   []mudrex.Order
+	trades   []Trade
+	fees     float64
+}
+
+type simPosition struct {
+	id         string
+	side       mudrex.OrderType
+	quantity   float64
+	entry      float64
+	leverage   float64
+	entryFees  float64
+	realized   float64
+	openedAt   time.Time
+	updatedAt  time.Time
+	stopLoss   *mudrex.RiskOrder
+	takeProfit *mudrex.RiskOrder
+}
+
+type simOrder struct {
+	order      mudrex.Order
+	side       mudrex.OrderType
+	quantity   float64
+	price      float64
+	leverage   float64
+	stopLoss   float64
+	takeProfit float64
+	reduceOnly bool
+}
+
+func newBroker(cfg Config) *Broker {
+	return &Broker{
+		assetID:  cfg.AssetID,
+		makerFee: cfg.MakerFee,
+		takerFee: cfg.TakerFee,
+		balance:  cfg.InitialBalance,
+	}
+}
+
+// Candle returns the candle currently being processed
+func (b *Broker) Candle() mudrex.Candle {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.candle
+}
+
+// Balance returns the wallet balance excluding unrealized P&L
+func (b *Broker) Balance() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.balance
+}
+
+// Equity returns the balance plus unrealized P&L at the current close
+func (b *Broker) Equity() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.equity()
+}
+
+func (b *Broker) equity() float64 {
+	return b.balance + b.unrealized(b.candle.Close)
+}
+
+func (b *Broker) unrealized(price float64) float64 {
+	if b.position == nil {
+		return 0
+	}
+	return pnl(b.position.side, b.position.quantity, b.position.entry, price)
+}
+
+func (b *Broker) usedMargin() float64 {
+	if b.position == nil {
+		return 0
+	}
+	return b.position.quantity * b.position.entry / b.position.leverage
+}
+
+// CreateOrder places a simulated order. Market orders fill immediately at the
+// current close with the taker fee; limit orders rest until the price crosses
+// them and fill with the maker fee. An order adding to the open position must
+// use the position's leverage.
+func (b *Broker) CreateOrder(assetID string, req *mudrex.OrderRequest) (*mudrex.Order, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if assetID != b.assetID {
+		return nil, validationError(fmt.Sprintf("unknown asset %q", assetID))
+	}
+
+	o, err := b.parseOrder(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.TriggerType {
+	case mudrex.TriggerTypeMarket:
+		if err := b.execute(o, b.candle.Close, b.takerFee, b.candle.Time); err != nil {
+			return nil, err
+		}
+	case mudrex.TriggerTypeLimit:
+		b.pending = append(b.pending, o)
+	default:
+		return nil, validationError(fmt.Sprintf("unsupported trigger type %q", req.TriggerType))
+	}
+
+	b.orders = append(b.orders, o.order)
+	order := o.order
+	return &order, nil
+}
+
+func (b *Broker) parseOrder(req *mudrex.OrderRequest) (*simOrder, error) {
+	if req.OrderType != mudrex.OrderTypeLong && req.OrderType != mudrex.OrderTypeShort {
+		return nil, validationError(fmt.Sprintf("invalid order type %q", req.OrderType))
+	}
+
+	quantity, err := parsePositive("quantity", req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	leverage, err := parsePositive("leverage", req.Leverage)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &simOrder{
+		side:       req.OrderType,
+		quantity:   quantity,
+		leverage:   leverage,
+		reduceOnly: req.ReduceOnly,
+	}
+
+	if req.TriggerType == mudrex.TriggerTypeLimit {
+		if req.Price == nil {
+			return nil, validationError("price is required for limit orders")
+		}
+		if o.price, err = parsePositive("price", *req.Price); err != nil {
+			return nil, err
+		}
+	}
+	if req.StopLossPrice != nil {
+		if o.stopLoss, err = parsePositive("stoploss_price", *req.StopLossPrice); err != nil {
+			return nil, err
+		}
+	}
+	if req.TakeProfitPrice != nil {
+		if o.takeProfit, err = parsePositive("takeprofit_price", *req.TakeProfitPrice); err != nil {
+			return nil, err
+		}
+	}
+
+	b.seq++
+	o.order = mudrex.Order{
+		OrderID:         fmt.Sprintf("bt-order-%d", b.seq),
+		Symbol:          b.assetID,
+		AssetID:         b.assetID,
+		OrderType:       req.OrderType,
+		TriggerType:     req.TriggerType,
+		Quantity:        req.Quantity,
+		FilledQuantity:  "0",
+		Status:          mudrex.OrderStatusOpen,
+		Leverage:        req.Leverage,
+		StopLossPrice:   req.StopLossPrice,
+		TakeProfitPrice: req.TakeProfitPrice,
+		CreatedAt:       mudrex.NewTimestamp(b.candle.Time),
+		UpdatedAt:       mudrex.NewTimestamp(b.candle.Time),
+		ReduceOnly:      req.ReduceOnly,
+	}
+	if req.Price != nil {
+		o.order.Price = *req.Price
+	}
+
+	return o, nil
+}
+
+// execute fills an order at price, netting against any existing position
+func (b *Broker) execute(o *simOrder, price, feeRate float64, at time.Time) error {
+	var closed, opened float64
+
+	if b.position != nil && b.position.side != o.side {
+		closed = math.Min(o.quantity, b.position.quantity)
+		b.closeQuantity(closed, price, feeRate, at, ExitSignal)
+	} else if o.reduceOnly {
+		return validationError("reduce-only order would increase position")
+	} else if b.position != nil && o.leverage != b.position.leverage {
+		// The position keeps one leverage; adding at another would check
+		// margin at a rate the position does not use.
+		return validationError(fmt.Sprintf("order leverage %s differs from the open position's %s",
+			formatFloat(o.leverage), formatFloat(b.position.leverage)))
+	}
+
+	if remaining := o.quantity - closed; remaining > 0 && !o.reduceOnly {
+		fee := remaining * price * feeRate
+		required := remaining*price/o.leverage + fee
+		free := b.balance - b.usedMargin() + math.Min(0, b.unrealized(price))
+		if required <= free {
+			b.open(o, remaining, price, fee, at)
+			opened = remaining
+		} else if closed == 0 {
+			return insufficientBalanceError(required, free)
+		}
+		// Otherwise the closing leg has executed and the opening leg is dropped.
+	}
+
+	o.order.Status = mudrex.OrderStatusFilled
+	o.order.FilledQuantity = formatFloat(closed + opened)
+	o.order.AvgFilledPrice = formatFloat(price)
+	o.order.UpdatedAt = mudrex.NewTimestamp(at)
+	return nil
+}
+
+func (b *Broker) open(o *simOrder, quantity, price, fee float64, at time.Time) {
+	b.balance -= fee
+	b.fees += fee
+
+	if b.position == nil {
+		b.seq++
+		b.position = &simPosition{
+			id:       fmt.Sprintf("bt-pos-%d", b.seq),
+			side:     o.side,
+			leverage: o.leverage,
+			openedAt: at,
+		}
+	}
+
+	p := b.position
+	p.entry = (p.entry*p.quantity + price*quantity) / (p.quantity + quantity)
+	p.quantity += quantity
+	p.entryFees += fee
+	p.updatedAt = at
+
+	if o.stopLoss > 0 {
+		p.stopLoss = b.riskOrder(p, mudrex.RiskOrderTypeStopLoss, o.stopLoss, at)
+	}
+	if o.takeProfit > 0 {
+		p.takeProfit = b.riskOrder(p, mudrex.RiskOrderTypeTakeProfit, o.takeProfit, at)
+	}
+}
+
+func (b *Broker) riskOrder(p *simPosition, kind mudrex.RiskOrderType, trigger float64, at time.Time) *mudrex.RiskOrder {
+	b.seq++
+	return &mudrex.RiskOrder{
+		OrderID:      fmt.Sprintf("bt-risk-%d", b.seq),
+		PositionID:   p.id,
+		OrderType:    kind,
+		TriggerPrice: formatFloat(trigger),
+		Status:       "OPEN",
+		CreatedAt:    mudrex.NewTimestamp(at),
+		UpdatedAt:    mudrex.NewTimestamp(at),
+	}
+}
+
+// closeQuantity realizes P&L on part or all of the open position
+func (b *Broker) closeQuantity(quantity, price, feeRate float64, at time.Time, reason string) {
+	p := b.position
+	fee := quantity * price * feeRate
+	gross := pnl(p.side, quantity, p.entry, price)
+	entryFees := p.entryFees * quantity / p.quantity
+
+	if reason == ExitLiquidation {
+		gross = -quantity * p.entry / p.leverage
+		fee = 0
+	}
+
+	b.balance += gross - fee
+	b.fees += fee
+	p.entryFees -= entryFees
+	p.realized += gross
+
+	b.trades = append(b.trades, Trade{
+		PositionID: p.id,
+		AssetID:    b.assetID,
+		Side:       p.side,
+		Quantity:   quantity,
+		Leverage:   p.leverage,
+		EntryPrice: p.entry,
+		ExitPrice:  price,
+		EntryTime:  p.openedAt,
+		ExitTime:   at,
+		GrossPnL:   gross,
+		Fees:       entryFees + fee,
+		NetPnL:     gross - entryFees - fee,
+		ExitReason: reason,
+	})
+
+	p.quantity -= quantity
+	p.updatedAt = at
+	if p.quantity <= 1e-12 {
+		b.position = nil
+	}
+}
+
+// CancelOrder cancels a resting limit order
+func (b *Broker) CancelOrder(assetID, orderID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, o := range b.pending {
+		if o.order.OrderID == orderID {
+			b.pending = append(b.pending[:i], b.pending[i+1:]...)
+			b.setOrderStatus(orderID, mudrex.OrderStatusCancelled)
+			return nil
+		}
+	}
+	return notFoundError(fmt.Sprintf("order %s not found", orderID))
+}
+
+// ClosePosition closes the position at the current close with the taker fee
+func (b *Broker) ClosePosition(positionID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.position == nil || b.position.id != positionID {
+		return notFoundError(fmt.Sprintf("position %s not found", positionID))
+	}
+	b.closeQuantity(b.position.quantity, b.candle.Close, b.takerFee, b.candle.Time, ExitSignal)
+	return nil
+}
+
+// SetStopLoss attaches or replaces the stop loss on a position
+func (b *Broker) SetStopLoss(positionID, triggerPrice string) (*mudrex.RiskOrder, error) {
+	return b.setRiskOrder(positionID, mudrex.RiskOrderTypeStopLoss, triggerPrice)
+}
+
+// SetTakeProfit attaches or replaces the take profit on a position
+func (b *Broker) SetTakeProfit(positionID, triggerPrice string) (*mudrex.RiskOrder, error) {
+	return b.setRiskOrder(positionID, mudrex.RiskOrderTypeTakeProfit, triggerPrice)
+}
+
+func (b *Broker) setRiskOrder(positionID string, kind mudrex.RiskOrderType, triggerPrice string) (*mudrex.RiskOrder, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.position == nil || b.position.id != positionID {
+		return nil, notFoundError(fmt.Sprintf("position %s not found", positionID))
+	}
+	trigger, err := parsePositive("trigger_price", triggerPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	ro := b.riskOrder(b.position, kind, trigger, b.candle.Time)
+	if kind == "STOP_LOSS" {
+		b.position.stopLoss = ro
+	} else {
+		b.position.takeProfit = ro
+	}
+	result := *ro
+	return &result, nil
+}
+
+// OpenPositions returns the open position, if any, as an SDK model
+func (b *Broker) OpenPositions() ([]mudrex.Position, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.position == nil {
+		return nil, nil
+	}
+	return []mudrex.Position{b.positionModel()}, nil
+}
+
+func (b *Broker) positionModel() mudrex.Position {
+	p := b.position
+	margin := p.quantity * p.entry / p.leverage
+	unrealized := b.unrealized(b.candle.Close)
+
+	pos := mudrex.Position{
+		PositionID:    p.id,
+		Symbol:        b.assetID,
+		AssetID:       b.assetID,
+		EntryPrice:    formatFloat(p.entry),
+		Quantity:      formatFloat(p.quantity),
+		Side:          p.side,
+		Status:        mudrex.PositionStatusOpen,
+		Leverage:      formatFloat(p.leverage),
+		UnrealizedPnL: formatFloat(unrealized),
+		RealizedPnL:   formatFloat(p.realized),
+		Margin:        formatFloat(margin),
+		MarginRatio:   formatFloat(math.Max(0, -unrealized) / margin),
+		MarkPrice:     formatFloat(b.candle.Close),
+		CreatedAt:     mudrex.NewTimestamp(p.openedAt),
+		UpdatedAt:     mudrex.NewTimestamp(p.updatedAt),
+	}
+	if p.stopLoss != nil {
+		sl := p.stopLoss.TriggerPrice
+		pos.StopLoss = &sl
+	}
+	if p.takeProfit != nil {
+		tp := p.takeProfit.TriggerPrice
+		pos.TakeProfit = &tp
+	}
+	return pos
+}
+
+// OpenOrders returns resting limit orders for the asset
+func (b *Broker) OpenOrders(assetID string) ([]mudrex.Order, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	orders := make([]mudrex.Order, 0, len(b.pending))
+	for _, o := range b.pending {
+		if assetID == "" || o.order.AssetID == assetID {
+			orders = append(orders, o.order)
+		}
+	}
+	return orders, nil
+}
+
+// Orders returns every order placed so far with its latest status
+func (b *Broker) Orders() []mudrex.Order {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	orders := make([]mudrex.Order, len(b.orders))
+	copy(orders, b.orders)
+	return orders
+}
+
+// Trades returns the trades closed so far
+func (b *Broker) Trades() []Trade {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	trades := make([]Trade, len(b.trades))
+	copy(trades, b.trades)
+	return trades
+}
+
+// advance moves the simulation to the next candle, filling resting orders
+// and triggering liquidation, stop loss and take profit within its range
+func (b *Broker) advance(c mudrex.Candle) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.candle = c
+	b.checkRisk(c)
+	b.fillPending(c)
+	b.checkRisk(c)
+}
+
+func (b *Broker) fillPending(c mudrex.Candle) {
+	remaining := b.pending[:0]
+	for _, o := range b.pending {
+		crossed := (o.side == mudrex.OrderTypeLong && c.Low <= o.price) ||
+			(o.side == mudrex.OrderTypeShort && c.High >= o.price)
+		if !crossed {
+			remaining = append(remaining, o)
+			continue
+		}
+
+		price := o.price
+		if (o.side == mudrex.OrderTypeLong && c.Open < price) || (o.side == mudrex.OrderTypeShort && c.Open > price) {
+			price = c.Open
+		}
+		if err := b.execute(o, price, b.makerFee, c.Time); err != nil {
+			o.order.Status = mudrex.OrderStatusCancelled
+			o.order.UpdatedAt = mudrex.NewTimestamp(c.Time)
+		}
+		b.replaceOrder(o.order)
+	}
+	b.pending = remaining
+}
+
+// checkRisk evaluates the open position against the candle's range. When
+// several triggers fall within one candle the most adverse outcome is assumed.
+func (b *Broker) checkRisk(c mudrex.Candle) {
+	p := b.position
+	if p == nil {
+		return
+	}
+
+	long := p.side == mudrex.OrderTypeLong
+	liquidation := p.entry * (1 - 1/p.leverage)
+	if !long {
+		liquidation = p.entry * (1 + 1/p.leverage)
+	}
+
+	adverse := func(level float64) bool {
+		if long {
+			return c.Low <= level
+		}
+		return c.High >= level
+	}
+	favorable := func(level float64) bool {
+		if long {
+			return c.High >= level
+		}
+		return c.Low <= level
+	}
+	gapFill := func(level float64, adverseSide bool) float64 {
+		if (adverseSide == long && c.Open < level) || (adverseSide != long && c.Open > level) {
+			return c.Open
+		}
+		return level
+	}
+
+	if p.stopLoss != nil {
+		sl, _ := strconv.ParseFloat(p.stopLoss.TriggerPrice, 64)
+		slBeforeLiq := (long && sl > liquidation) || (!long && sl < liquidation)
+		if slBeforeLiq && adverse(sl) {
+			b.closeQuantity(p.quantity, gapFill(sl, true), b.takerFee, c.Time, ExitStopLoss)
+			return
+		}
+	}
+
+	if adverse(liquidation) {
+		b.closeQuantity(p.quantity, liquidation, 0, c.Time, ExitLiquidation)
+		return
+	}
+
+	if p.takeProfit != nil {
+		tp, _ := strconv.ParseFloat(p.takeProfit.TriggerPrice, 64)
+		if favorable(tp) {
+			b.closeQuantity(p.quantity, gapFill(tp, false), b.takerFee, c.Time, ExitTakeProfit)
+		}
+	}
+}
+
+// closeAll flattens any open position at the current close
+func (b *Broker) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.position != nil {
+		b.closeQuantity(b.position.quantity, b.candle.Close, b.takerFee, b.candle.Time, ExitEndOfData)
+	}
+	for _, o := range b.pending {
+		o.order.Status = mudrex.OrderStatusExpired
+		o.order.UpdatedAt = mudrex.NewTimestamp(b.candle.Time)
+		b.replaceOrder(o.order)
+	}
+	b.pending = nil
+}
+
+func (b *Broker) replaceOrder(order mudrex.Order) {
+	for i := range b.orders {
+		if b.orders[i].OrderID == order.OrderID {
+			b.orders[i] = order
+			return
+		}
+	}
+}
+
+func (b *Broker) setOrderStatus(orderID string, status mudrex.OrderStatus) {
+	for i := range b.orders {
+		if b.orders[i].OrderID == orderID {
+			b.orders[i].Status = status
+			b.orders[i].UpdatedAt = mudrex.NewTimestamp(b.candle.Time)
+			return
+		}
+	}
+}
+
+func (b *Broker) sortedTrades() []Trade {
+	trades := b.Trades()
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].ExitTime.Before(trades[j].ExitTime)
+	})
+	return trades
+}
+
+func pnl(side mudrex.OrderType, quantity, entry, price float64) float64 {
+	if side == mudrex.OrderTypeShort {
+		return (entry - price) * quantity
+	}
+	return (price - entry) * quantity
+}
+
+func parsePositive(name, value string) (float64, error) {
+	f, err := mudrex.ParseDecimal(name, value)
+	if err != nil || f <= 0 {
+		return 0, validationError(fmt.Sprintf("invalid %s %q", name, value))
+	}
+	return f, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func validationError(msg string) error {
+	return &mudrex.ValidationError{MudrexError: &mudrex.MudrexError{Code: 400, Message: msg, Status: 400}}
+}
+
+func notFoundError(msg string) error {
+	return &mudrex.NotFoundError{MudrexError: &mudrex.MudrexError{Code: 404, Message: msg, Status: 404}}
+}
+
+func insufficientBalanceError(required, free float64) error {
+	return &mudrex.InsufficientBalanceError{MudrexError: &mudrex.MudrexError{
+		Code:    1002,
+		Message: fmt.Sprintf("insufficient balance: required %s, available %s", formatFloat(required), formatFloat(free)),
+		Status:  400,
+	}}
+}
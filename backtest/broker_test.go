@@ -0,0 +1,311 @@
+package backtest
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func bar(i int, open, high, low, close float64) mudrex.Candle {
+	return mudrex.Candle{Time: start.Add(time.Duration(i) * time.Hour), Open: open, High: high, Low: low, Close: close}
+}
+
+func newTestBroker() *Broker {
+	return newBroker(Config{AssetID: "BTCUSDT", InitialBalance: 1000, MakerFee: 0.0005, TakerFee: 0.001})
+}
+
+func str(s string) *string { return &s }
+
+func market(side mudrex.OrderType, quantity, leverage string) *mudrex.OrderRequest {
+	return &mudrex.OrderRequest{
+		Leverage:    leverage,
+		Quantity:    quantity,
+		OrderType:   side,
+		TriggerType: mudrex.TriggerTypeMarket,
+	}
+}
+
+func approx(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestMarketOrderRoundTrip(t *testing.T) {
+	b := newTestBroker()
+	b.advance(bar(0, 100, 100, 100, 100))
+
+	order, err := b.CreateOrder("BTCUSDT", market(mudrex.OrderTypeLong, "1", "10"))
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if order.Status != mudrex.OrderStatusFilled || order.FilledQuantity != "1" || order.AvgFilledPrice != "100" {
+		t.Errorf("order = %+v, want filled 1 at 100", order)
+	}
+	approx(t, "balance after entry fee", b.Balance(), 999.9)
+
+	b.advance(bar(1, 100, 110, 100, 110))
+	positions, _ := b.OpenPositions()
+	if len(positions) != 1 {
+		t.Fatalf("got %d positions, want 1", len(positions))
+	}
+	approx(t, "equity", b.Equity(), 1009.9)
+
+	if err := b.ClosePosition(positions[0].PositionID); err != nil {
+		t.Fatalf("ClosePosition: %v", err)
+	}
+	trades := b.Trades()
+	if len(trades) != 1 {
+		t.Fatalf("got %d trades, want 1", len(trades))
+	}
+	tr := trades[0]
+	if tr.ExitReason != ExitSignal || tr.EntryPrice != 100 || tr.ExitPrice != 110 {
+		t.Errorf("trade = %+v, want a signal exit from 100 to 110", tr)
+	}
+	approx(t, "gross P&L", tr.GrossPnL, 10)
+	approx(t, "fees", tr.Fees, 0.1+0.11)
+	approx(t, "net P&L", tr.NetPnL, 10-0.21)
+	approx(t, "balance", b.Balance(), 1000+10-0.21)
+}
+
+func TestLimitOrderFills(t *testing.T) {
+	tests := []struct {
+		name   string
+		candle mudrex.Candle
+		filled bool
+		price  float64
+	}{
+		{"not crossed", bar(1, 100, 101, 96, 99), false, 0},
+		{"crossed", bar(1, 100, 101, 94, 99), true, 95},
+		{"gap through the limit", bar(1, 90, 92, 88, 91), true, 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker()
+			b.advance(bar(0, 100, 100, 100, 100))
+			req := market(mudrex.OrderTypeLong, "2", "5")
+			req.TriggerType = mudrex.TriggerTypeLimit
+			req.Price = str("95")
+			if _, err := b.CreateOrder("BTCUSDT", req); err != nil {
+				t.Fatalf("CreateOrder: %v", err)
+			}
+
+			b.advance(tt.candle)
+			positions, _ := b.OpenPositions()
+			open, _ := b.OpenOrders("BTCUSDT")
+			if !tt.filled {
+				if len(positions) != 0 || len(open) != 1 {
+					t.Errorf("got %d positions and %d open orders, want 0 and 1", len(positions), len(open))
+				}
+				return
+			}
+			if len(positions) != 1 || len(open) != 0 {
+				t.Fatalf("got %d positions and %d open orders, want 1 and 0", len(positions), len(open))
+			}
+			if positions[0].EntryPrice != formatFloat(tt.price) {
+				t.Errorf("entry = %s, want %v", positions[0].EntryPrice, tt.price)
+			}
+			// Limit fills pay the maker fee
+			approx(t, "balance", b.Balance(), 1000-2*tt.price*0.0005)
+		})
+	}
+}
+
+func TestRiskExits(t *testing.T) {
+	tests := []struct {
+		name       string
+		side       mudrex.OrderType
+		stopLoss   string
+		takeProfit string
+		candle     mudrex.Candle
+		reason     string
+		exit       float64
+	}{
+		{"long stop loss", mudrex.OrderTypeLong, "95", "", bar(1, 99, 100, 94, 96), ExitStopLoss, 95},
+		{"long stop loss gapped through", mudrex.OrderTypeLong, "95", "", bar(1, 93, 94, 92, 93), ExitStopLoss, 93},
+		{"long take profit", mudrex.OrderTypeLong, "", "110", bar(1, 101, 111, 100, 108), ExitTakeProfit, 110},
+		{"short stop loss", mudrex.OrderTypeShort, "105", "", bar(1, 101, 106, 100, 104), ExitStopLoss, 105},
+		{"short take profit", mudrex.OrderTypeShort, "", "90", bar(1, 99, 100, 89, 92), ExitTakeProfit, 90},
+		{"both in one candle takes the stop", mudrex.OrderTypeLong, "95", "110", bar(1, 100, 111, 94, 105), ExitStopLoss, 95},
+		{"liquidation without a stop", mudrex.OrderTypeLong, "", "", bar(1, 95, 96, 89, 91), ExitLiquidation, 90},
+		{"stop beyond liquidation", mudrex.OrderTypeLong, "85", "", bar(1, 95, 96, 84, 91), ExitLiquidation, 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker()
+			b.advance(bar(0, 100, 100, 100, 100))
+			req := market(tt.side, "1", "10")
+			if tt.stopLoss != "" {
+				req.StopLossPrice = str(tt.stopLoss)
+			}
+			if tt.takeProfit != "" {
+				req.TakeProfitPrice = str(tt.takeProfit)
+			}
+			if _, err := b.CreateOrder("BTCUSDT", req); err != nil {
+				t.Fatalf("CreateOrder: %v", err)
+			}
+
+			b.advance(tt.candle)
+			trades := b.Trades()
+			if len(trades) != 1 {
+				t.Fatalf("got %d trades, want 1", len(trades))
+			}
+			if trades[0].ExitReason != tt.reason || trades[0].ExitPrice != tt.exit {
+				t.Errorf("exit = %s at %v, want %s at %v", trades[0].ExitReason, trades[0].ExitPrice, tt.reason, tt.exit)
+			}
+			if positions, _ := b.OpenPositions(); len(positions) != 0 {
+				t.Errorf("position still open: %+v", positions)
+			}
+			if tt.reason == ExitLiquidation {
+				// The whole margin of 1 * 100 / 10 is lost, without an exit fee
+				approx(t, "gross P&L", trades[0].GrossPnL, -10)
+			}
+		})
+	}
+}
+
+func TestSetStopLossOnOpenPosition(t *testing.T) {
+	b := newTestBroker()
+	b.advance(bar(0, 100, 100, 100, 100))
+	if _, err := b.CreateOrder("BTCUSDT", market(mudrex.OrderTypeLong, "1", "10")); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	positions, _ := b.OpenPositions()
+	if _, err := b.SetStopLoss(positions[0].PositionID, "97"); err != nil {
+		t.Fatalf("SetStopLoss: %v", err)
+	}
+	if _, err := b.SetStopLoss("missing", "97"); err == nil {
+		t.Error("SetStopLoss on an unknown position succeeded")
+	}
+
+	b.advance(bar(1, 99, 99, 96, 98))
+	if trades := b.Trades(); len(trades) != 1 || trades[0].ExitPrice != 97 {
+		t.Errorf("trades = %+v, want one stop exit at 97", trades)
+	}
+}
+
+func TestOrderRejections(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup *mudrex.OrderRequest
+		req   *mudrex.OrderRequest
+	}{
+		{name: "insufficient balance", req: market(mudrex.OrderTypeLong, "100", "1")},
+		{name: "NaN quantity", req: market(mudrex.OrderTypeLong, "NaN", "10")},
+		{name: "infinite leverage", req: market(mudrex.OrderTypeLong, "1", "Inf")},
+		{name: "invalid side", req: market("SIDEWAYS", "1", "10")},
+		{
+			name: "limit without price",
+			req:  &mudrex.OrderRequest{Leverage: "10", Quantity: "1", OrderType: mudrex.OrderTypeLong, TriggerType: mudrex.TriggerTypeLimit},
+		},
+		{
+			name: "reduce-only increase",
+			req: &mudrex.OrderRequest{
+				Leverage: "10", Quantity: "1", OrderType: mudrex.OrderTypeLong,
+				TriggerType: mudrex.TriggerTypeMarket, ReduceOnly: true,
+			},
+		},
+		{
+			name:  "adding at another leverage",
+			setup: market(mudrex.OrderTypeLong, "1", "5"),
+			req:   market(mudrex.OrderTypeLong, "1", "10"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker()
+			b.advance(bar(0, 100, 100, 100, 100))
+			if tt.setup != nil {
+				if _, err := b.CreateOrder("BTCUSDT", tt.setup); err != nil {
+					t.Fatalf("setup order: %v", err)
+				}
+			}
+			balance := b.Balance()
+
+			if _, err := b.CreateOrder("BTCUSDT", tt.req); err == nil {
+				t.Fatal("CreateOrder succeeded, want error")
+			}
+			if b.Balance() != balance {
+				t.Errorf("balance changed from %v to %v", balance, b.Balance())
+			}
+		})
+	}
+}
+
+func TestOppositeOrderReverses(t *testing.T) {
+	b := newTestBroker()
+	b.advance(bar(0, 100, 100, 100, 100))
+	if _, err := b.CreateOrder("BTCUSDT", market(mudrex.OrderTypeLong, "1", "10")); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	b.advance(bar(1, 100, 105, 100, 105))
+	order, err := b.CreateOrder("BTCUSDT", market(mudrex.OrderTypeShort, "3", "10"))
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if order.FilledQuantity != "3" {
+		t.Errorf("filled = %s, want 3", order.FilledQuantity)
+	}
+
+	positions, _ := b.OpenPositions()
+	if len(positions) != 1 || positions[0].Side != mudrex.OrderTypeShort || positions[0].Quantity != "2" {
+		t.Errorf("positions = %+v, want a short of 2", positions)
+	}
+	if trades := b.Trades(); len(trades) != 1 || trades[0].GrossPnL != 5 {
+		t.Errorf("trades = %+v, want the long closed for 5", trades)
+	}
+}
+
+func TestEngineRun(t *testing.T) {
+	candles := []mudrex.Candle{
+		bar(0, 100, 100, 100, 100),
+		bar(1, 100, 110, 100, 110),
+		bar(2, 110, 120, 110, 120),
+		bar(3, 120, 130, 120, 130),
+	}
+	engine, err := NewEngine(Config{AssetID: "BTCUSDT", InitialBalance: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := engine.Run(candles, func(ctx *Context) error {
+		switch ctx.Index {
+		case 0:
+			_, err := ctx.Broker.CreateOrder("BTCUSDT", market(mudrex.OrderTypeLong, "1", "10"))
+			return err
+		case 2:
+			return ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// The stopping candle keeps its equity point, and the position is
+	// closed at its close
+	if len(report.EquityCurve) != 3 {
+		t.Fatalf("got %d equity points, want 3", len(report.EquityCurve))
+	}
+	approx(t, "final equity", report.FinalEquity, 1020)
+	approx(t, "last equity point", report.EquityCurve[2].Equity, 1020)
+	if len(report.Trades) != 1 || report.Trades[0].ExitReason != ExitEndOfData {
+		t.Errorf("trades = %+v, want one end-of-data exit", report.Trades)
+	}
+	approx(t, "win rate", report.WinRate, 1)
+}
+
+func TestEngineRunStrategyError(t *testing.T) {
+	engine, _ := NewEngine(Config{AssetID: "BTCUSDT", InitialBalance: 1000})
+	boom := errors.New("boom")
+	_, err := engine.Run([]mudrex.Candle{bar(0, 1, 1, 1, 1)}, func(*Context) error { return boom })
+	if !errors.Is(err, boom) {
+		t.Errorf("Run error = %v, want boom", err)
+	}
+}
@@ -0,0 +1,247 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// LoadCandles reads candles from a .csv or .json file, chosen by extension
+func LoadCandles(path string) ([]mudrex.Candle, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return LoadCSV(path)
+	case ".json":
+		return LoadJSON(path)
+	default:
+		return nil, fmt.Errorf("unsupported candle file extension: %q", filepath.Ext(path))
+	}
+}
+
+// LoadCSV reads candles from a CSV file
+func LoadCSV(path string) ([]mudrex.Candle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open candle file: %w", err)
+	}
+	defer f.Close()
+
+	return ReadCSV(f)
+}
+
+// LoadJSON reads candles from a JSON file
+func LoadJSON(path string) ([]mudrex.Candle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open candle file: %w", err)
+	}
+	defer f.Close()
+
+	return ReadJSON(f)
+}
+
+// ReadCSV parses candles from CSV. An optional header row names the columns
+// (time/timestamp, open, high, low, close, volume); without one the columns
+// are assumed to be in that order. Times may be RFC 3339 or epoch seconds/ms.
+func ReadCSV(r io.Reader) ([]mudrex.Candle, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{"time": 0, "open": 1, "high": 2, "low": 3, "close": 4, "volume": 5}
+	if _, err := parseTime(records[0][0]); err != nil {
+		columns = make(map[string]int)
+		for i, name := range records[0] {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "timestamp" || name == "date" {
+				name = "time"
+			}
+			columns[name] = i
+		}
+		records = records[1:]
+		for _, required := range []string{"time", "open", "high", "low", "close"} {
+			if _, ok := columns[required]; !ok {
+				return nil, fmt.Errorf("CSV header is missing column %q", required)
+			}
+		}
+	}
+
+	candles := make([]mudrex.Candle, 0, len(records))
+	for line, record := range records {
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		var c mudrex.Candle
+		if c.Time, err = parseTime(field("time")); err != nil {
+			return nil, fmt.Errorf("row %d: %w", line+1, err)
+		}
+		for _, col := range []struct {
+			name string
+			dst  *float64
+		}{
+			{"open", &c.Open}, {"high", &c.High}, {"low", &c.Low}, {"close", &c.Close}, {"volume", &c.Volume},
+		} {
+			value := field(col.name)
+			if value == "" && col.name == "volume" {
+				continue
+			}
+			if *col.dst, err = strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("row %d: invalid %s %q", line+1, col.name, value)
+			}
+		}
+		candles = append(candles, c)
+	}
+
+	sortCandles(candles)
+	return candles, nil
+}
+
+// ReadJSON parses candles from a JSON array of either objects
+// ({"time":..., "open":..., ...}) or arrays ([time, open, high, low, close, volume]).
+// Numbers may be encoded as JSON numbers or strings.
+func ReadJSON(r io.Reader) ([]mudrex.Candle, error) {
+	var rows []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("failed to parse candles: %w", err)
+	}
+
+	candles := make([]mudrex.Candle, 0, len(rows))
+	for i, row := range rows {
+		c, err := decodeJSONCandle(row)
+		if err != nil {
+			return nil, fmt.Errorf("candle %d: %w", i, err)
+		}
+		candles = append(candles, c)
+	}
+
+	sortCandles(candles)
+	return candles, nil
+}
+
+func decodeJSONCandle(row json.RawMessage) (mudrex.Candle, error) {
+	var c mudrex.Candle
+
+	var values []flexValue
+	if err := json.Unmarshal(row, &values); err == nil {
+		if len(values) < 5 {
+			return c, fmt.Errorf("expected at least 5 values, got %d", len(values))
+		}
+		values = append(values, "")
+		return buildCandle(values[0], values[1], values[2], values[3], values[4], values[5])
+	}
+
+	var obj struct {
+		Time      flexValue `json:"time"`
+		Timestamp flexValue `json:"timestamp"`
+		Open      flexValue `json:"open"`
+		High      flexValue `json:"high"`
+		Low       flexValue `json:"low"`
+		Close     flexValue `json:"close"`
+		Volume    flexValue `json:"volume"`
+	}
+	if err := json.Unmarshal(row, &obj); err != nil {
+		return c, err
+	}
+	if obj.Time == "" {
+		obj.Time = obj.Timestamp
+	}
+	return buildCandle(obj.Time, obj.Open, obj.High, obj.Low, obj.Close, obj.Volume)
+}
+
+func buildCandle(t, open, high, low, closePrice, volume flexValue) (mudrex.Candle, error) {
+	var c mudrex.Candle
+	var err error
+
+	if c.Time, err = parseTime(string(t)); err != nil {
+		return c, err
+	}
+	if c.Open, err = open.float("open"); err != nil {
+		return c, err
+	}
+	if c.High, err = high.float("high"); err != nil {
+		return c, err
+	}
+	if c.Low, err = low.float("low"); err != nil {
+		return c, err
+	}
+	if c.Close, err = closePrice.float("close"); err != nil {
+		return c, err
+	}
+	if volume != "" {
+		if c.Volume, err = volume.float("volume"); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// flexValue accepts a JSON number or string
+type flexValue string
+
+func (v *flexValue) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = flexValue(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*v = flexValue(n.String())
+	return nil
+}
+
+func (v flexValue) float(name string) (float64, error) {
+	f, err := strconv.ParseFloat(string(v), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, string(v))
+	}
+	return f, nil
+}
+
+// parseTime accepts RFC 3339, YYYY-MM-DD, or epoch seconds/milliseconds
+func parseTime(s string) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > 1e12 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+func sortCandles(candles []mudrex.Candle) {
+	sort.SliceStable(candles, func(i, j int) bool {
+		return candles[i].Time.Before(candles[j].Time)
+	})
+}
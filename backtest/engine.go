@@ -0,0 +1,99 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// Config configures a backtest run
+type Config struct {
+	AssetID        string
+	InitialBalance float64
+	// MakerFee and TakerFee are fee rates, e.g. 0.0002 for 0.02%
+	MakerFee float64
+	TakerFee float64
+	// PeriodsPerYear annualizes the Sharpe ratio. When zero it is inferred
+	// from the median spacing between candles.
+	PeriodsPerYear float64
+	// RiskFreeRate is the annual risk-free rate used by the Sharpe ratio
+	RiskFreeRate float64
+}
+
+// Context is passed to the strategy for every candle
+type Context struct {
+	// Index is the position of Candle within the replayed series
+	Index int
+	// Candle is the bar that just closed
+	Candle mudrex.Candle
+	// History holds every candle up to and including Candle
+	History []mudrex.Candle
+	// Broker executes orders against the simulation
+	Broker *Broker
+}
+
+// Strategy is called once per candle after resting orders and risk orders
+// have been evaluated against it
+type Strategy func(ctx *Context) error
+
+// ErrStop can be returned by a strategy to end the run early without error
+var ErrStop = errors.New("backtest: stop")
+
+// Engine replays candles through a strategy
+type Engine struct {
+	config Config
+}
+
+// NewEngine creates a new backtest engine
+func NewEngine(config Config) (*Engine, error) {
+	if config.AssetID == "" {
+		return nil, fmt.Errorf("asset ID is required")
+	}
+	if config.InitialBalance <= 0 {
+		return nil, fmt.Errorf("initial balance must be positive")
+	}
+	if config.MakerFee < 0 || config.TakerFee < 0 {
+		return nil, fmt.Errorf("fee rates must not be negative")
+	}
+
+	return &Engine{config: config}, nil
+}
+
+// Run replays candles through strategy and returns the resulting report.
+// Any position still open after the last candle is closed at its close.
+func (e *Engine) Run(candles []mudrex.Candle, strategy Strategy) (*Report, error) {
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles to replay")
+	}
+
+	broker := newBroker(e.config)
+	equity := make([]EquityPoint, 0, len(candles))
+
+	for i, c := range candles {
+		broker.advance(c)
+
+		ctx := &Context{
+			Index:   i,
+			Candle:  c,
+			History: candles[:i+1],
+			Broker:  broker,
+		}
+		err := strategy(ctx)
+		if err != nil && !errors.Is(err, ErrStop) {
+			return nil, fmt.Errorf("strategy failed at %s: %w", c.Time, err)
+		}
+
+		equity = append(equity, EquityPoint{Time: c.Time, Equity: broker.Equity()})
+		if err != nil {
+			break
+		}
+	}
+
+	broker.closeAll()
+	if n := len(equity); n > 0 {
+		equity[n-1].Equity = broker.Equity()
+	}
+
+	return newReport(e.config, broker, equity, candles), nil
+}
@@ -0,0 +1,138 @@
+package backtest
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// EquityPoint is the account equity at the close of a candle
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Report summarizes a backtest run
+type Report struct {
+	InitialBalance float64 `json:"initial_balance"`
+	FinalEquity    float64 `json:"final_equity"`
+	NetProfit      float64 `json:"net_profit"`
+	ReturnPct      float64 `json:"return_pct"`
+	TotalFees      float64 `json:"total_fees"`
+	// MaxDrawdown is the largest peak-to-trough equity decline as a fraction
+	MaxDrawdown float64 `json:"max_drawdown"`
+	// Sharpe is the annualized Sharpe ratio of per-candle equity returns
+	Sharpe      float64        `json:"sharpe"`
+	WinRate     float64        `json:"win_rate"`
+	Trades      []Trade        `json:"trades"`
+	Orders      []mudrex.Order `json:"orders"`
+	EquityCurve []EquityPoint  `json:"equity_curve"`
+}
+
+func newReport(cfg Config, broker *Broker, equity []EquityPoint, candles []mudrex.Candle) *Report {
+	r := &Report{
+		InitialBalance: cfg.InitialBalance,
+		FinalEquity:    broker.Equity(),
+		Trades:         broker.sortedTrades(),
+		Orders:         broker.Orders(),
+		EquityCurve:    equity,
+	}
+	r.NetProfit = r.FinalEquity - r.InitialBalance
+	r.ReturnPct = r.NetProfit / r.InitialBalance * 100
+
+	broker.mu.Lock()
+	r.TotalFees = broker.fees
+	broker.mu.Unlock()
+
+	wins := 0
+	for _, t := range r.Trades {
+		if t.NetPnL > 0 {
+			wins++
+		}
+	}
+	if len(r.Trades) > 0 {
+		r.WinRate = float64(wins) / float64(len(r.Trades))
+	}
+
+	r.MaxDrawdown = maxDrawdown(cfg.InitialBalance, equity)
+
+	periods := cfg.PeriodsPerYear
+	if periods <= 0 {
+		periods = inferPeriodsPerYear(candles)
+	}
+	r.Sharpe = sharpe(cfg.InitialBalance, equity, periods, cfg.RiskFreeRate)
+
+	return r
+}
+
+func maxDrawdown(initial float64, equity []EquityPoint) float64 {
+	peak := initial
+	worst := 0.0
+	for _, p := range equity {
+		if p.Equity > peak {
+			peak = p.Equity
+		}
+		if peak > 0 {
+			if dd := (peak - p.Equity) / peak; dd > worst {
+				worst = dd
+			}
+		}
+	}
+	return worst
+}
+
+func sharpe(initial float64, equity []EquityPoint, periodsPerYear, riskFree float64) float64 {
+	if len(equity) < 2 || periodsPerYear <= 0 {
+		return 0
+	}
+
+	returns := make([]float64, 0, len(equity))
+	prev := initial
+	for _, p := range equity {
+		if prev > 0 {
+			returns = append(returns, p.Equity/prev-1-riskFree/periodsPerYear)
+		}
+		prev = p.Equity
+	}
+	// A sample standard deviation needs two returns
+	if len(returns) < 2 {
+		return 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+	return mean / std * math.Sqrt(periodsPerYear)
+}
+
+func inferPeriodsPerYear(candles []mudrex.Candle) float64 {
+	if len(candles) < 2 {
+		return 0
+	}
+
+	gaps := make([]time.Duration, 0, len(candles)-1)
+	for i := 1; i < len(candles); i++ {
+		if d := candles[i].Time.Sub(candles[i-1].Time); d > 0 {
+			gaps = append(gaps, d)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0
+	}
+
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return float64(365*24*time.Hour) / float64(gaps[len(gaps)/2])
+}
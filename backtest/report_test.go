@@ -0,0 +1,90 @@
+package backtest
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+func curve(values ...float64) []EquityPoint {
+	points := make([]EquityPoint, len(values))
+	for i, v := range values {
+		points[i] = EquityPoint{Time: start.Add(time.Duration(i) * time.Hour), Equity: v}
+	}
+	return points
+}
+
+func TestSharpe(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial float64
+		equity  []EquityPoint
+		want    float64
+	}{
+		{"too few points", 100, curve(110), 0},
+		{"no usable returns", 0, curve(0, 0), 0},
+		// After equity hits zero only the first return can be computed
+		{"one usable return", 100, curve(0, 0, 0), 0},
+		{"flat", 100, curve(100, 100, 100), 0},
+		// Returns +10% and -10%: mean 0
+		{"symmetric", 100, curve(110, 99), 0},
+		// Returns 0.1, 0.2, 0.1: mean 0.4/3, sample std sqrt(1/300)
+		{"rising", 100, curve(110, 132, 145.2), 0.4 / 3 / math.Sqrt(1.0/300)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sharpe(tt.initial, tt.equity, 1, 0)
+			if math.IsNaN(got) || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("sharpe = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		equity []EquityPoint
+		want   float64
+	}{
+		{"only rising", curve(110, 120), 0},
+		{"below the initial balance", curve(90, 95), 0.1},
+		{"from a later peak", curve(200, 150, 210), 0.25},
+		{"to zero", curve(50, 0), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maxDrawdown(100, tt.equity); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("maxDrawdown = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferPeriodsPerYear(t *testing.T) {
+	hourly := []mudrex.Candle{bar(0, 1, 1, 1, 1), bar(1, 1, 1, 1, 1), bar(3, 1, 1, 1, 1), bar(4, 1, 1, 1, 1)}
+	if got := inferPeriodsPerYear(hourly); got != 365*24 {
+		t.Errorf("inferPeriodsPerYear = %v, want %v", got, 365*24)
+	}
+	if got := inferPeriodsPerYear(hourly[:1]); got != 0 {
+		t.Errorf("inferPeriodsPerYear of one candle = %v, want 0", got)
+	}
+}
+
+func TestReportMarshalsAfterTotalLoss(t *testing.T) {
+	b := newTestBroker()
+	b.advance(bar(0, 100, 100, 100, 100))
+	b.balance = 0
+
+	report := newReport(Config{InitialBalance: 1000, PeriodsPerYear: 8760}, b, curve(0, 0), nil)
+	if _, err := json.Marshal(report); err != nil {
+		t.Errorf("json.Marshal(report): %v", err)
+	}
+	if report.Sharpe != 0 || report.MaxDrawdown != 1 || report.ReturnPct != -100 {
+		t.Errorf("report = sharpe %v, drawdown %v, return %v%%; want 0, 1, -100%%",
+			report.Sharpe, report.MaxDrawdown, report.ReturnPct)
+	}
+}
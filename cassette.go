@@ -0,0 +1,314 @@
+package mudrex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CassetteVersion is the fixture format version written by the recorder
+const CassetteVersion = 1
+
+// redactedValue replaces scrubbed header values in recorded fixtures
+const redactedValue = "REDACTED"
+
+// scrubbedHeaders are never written to a cassette in clear text
+var scrubbedHeaders = []string{"X-Authentication", "Authorization"}
+
+// RecorderMode selects whether a Recorder talks to the network or a cassette
+type RecorderMode int
+
+const (
+	// ModeRecord forwards requests to the real transport and captures them
+	ModeRecord RecorderMode = iota
+	// ModeReplay serves responses from a cassette without network access
+	ModeReplay
+)
+
+// MatchMode controls how replayed requests are matched to recorded ones
+type MatchMode int
+
+const (
+	// MatchStrict requires method, path, query and body to be identical
+	MatchStrict MatchMode = iota
+	// MatchLenient only requires method and path to be identical
+	MatchLenient
+)
+
+// Cassette is a versioned set of recorded request/response pairs
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the captured form of an outgoing request
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the captured form of a server response
+type RecordedResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette
+type Recorder struct {
+	mu        sync.Mutex
+	path      string
+	mode      RecorderMode
+	match     MatchMode
+	transport http.RoundTripper
+	cassette  *Cassette
+	played    []bool
+}
+
+// NewRecorder creates a recorder that captures traffic sent through transport.
+// If transport is nil, http.DefaultTransport is used. Call Save to write the cassette.
+func NewRecorder(path string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &Recorder{
+		path:      path,
+		mode:      ModeRecord,
+		transport: transport,
+		cassette:  &Cassette{Version: CassetteVersion},
+	}
+}
+
+// NewReplayer creates a recorder that serves responses from the cassette at path
+func NewReplayer(path string, match MatchMode) (*Recorder, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		path:     path,
+		mode:     ModeReplay,
+		match:    match,
+		cassette: cassette,
+		played:   make([]bool, len(cassette.Interactions)),
+	}, nil
+}
+
+// LoadCassette reads and validates a cassette file
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette: %w", err)
+	}
+
+	if cassette.Version != CassetteVersion {
+		return nil, fmt.Errorf("unsupported cassette version %d (want %d)", cassette.Version, CassetteVersion)
+	}
+
+	return &cassette, nil
+}
+
+// Mode returns the mode the recorder was created in
+func (r *Recorder) Mode() RecorderMode {
+	return r.mode
+}
+
+// Cassette returns the interactions recorded or loaded so far
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interactions := make([]Interaction, len(r.cassette.Interactions))
+	copy(interactions, r.cassette.Interactions)
+	return Cassette{Version: r.cassette.Version, Interactions: interactions}
+}
+
+// Unplayed returns the number of recorded interactions not yet served in replay mode
+func (r *Recorder) Unplayed() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, played := range r.played {
+		if !played {
+			n++
+		}
+	}
+	return n
+}
+
+// Save writes the recorded cassette to disk. It is a no-op in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: scrubHeaders(req.Header),
+			Body:    string(body),
+		},
+		Response: RecordedResponse{
+			Status:  resp.StatusCode,
+			Headers: scrubHeaders(resp.Header),
+			Body:    string(respBody),
+		},
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.played[i] || !r.matches(interaction.Request, req, body) {
+			continue
+		}
+		r.played[i] = true
+
+		recorded := interaction.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+			StatusCode:    recorded.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Headers.Clone(),
+			Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded interaction matches %s %s", req.Method, req.URL.RequestURI())
+}
+
+func (r *Recorder) matches(recorded RecordedRequest, req *http.Request, body []byte) bool {
+	if recorded.Method != req.Method {
+		return false
+	}
+
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil || recordedURL.Path != req.URL.Path {
+		return false
+	}
+
+	if r.match == MatchLenient {
+		return true
+	}
+
+	if recordedURL.Query().Encode() != req.URL.Query().Encode() {
+		return false
+	}
+
+	return equalBodies([]byte(recorded.Body), body)
+}
+
+// equalBodies compares request bodies, ignoring JSON whitespace differences
+func equalBodies(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return false
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+func scrubHeaders(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+
+	scrubbed := h.Clone()
+	for _, name := range scrubbedHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, redactedValue)
+		}
+	}
+	return scrubbed
+}
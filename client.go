@@ -1,8 +1,11 @@
 package mudrex
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"sync"
 	"time"
@@ -10,7 +13,7 @@ import (
 
 // Client is the main Mudrex API client
 type Client struct {
-	apiSecret string
+	secrets   SecretProvider
 	baseURL   string
 	timeout   time.Duration
 	httpClient *http.Client
@@ -22,9 +25,17 @@ type Client struct {
 	Orders    *OrdersAPI
 	Positions *PositionsAPI
 	Fees      *FeesAPI
+	Market    *MarketAPI
 	
 	// Rate limiting
 	rateLimiter *RateLimiter
+	
+	// debug receives diagnostics when set with SetDebug
+	debug *log.Logger
+	
+	// Response metadata, see SetRetainRaw and OnResponse
+	retainRaw  bool
+	onResponse func(*ResponseMeta)
 }
 
 // RateLimiter implements simple rate limiting
@@ -41,6 +52,13 @@ func NewRateLimiter() *RateLimiter {
 	}
 }
 
+// NewRateLimiterWithRate creates a rate limiter allowing requestsPerSecond requests
+func NewRateLimiterWithRate(requestsPerSecond float64) *RateLimiter {
+	return &RateLimiter{
+		minInterval: time.Duration(float64(time.Second) / requestsPerSecond),
+	}
+}
+
 // Wait blocks until the rate limit allows the next request
 func (rl *RateLimiter) Wait() {
 	rl.mu.Lock()
@@ -58,17 +76,23 @@ func (rl *RateLimiter) Wait() {
 
 // NewClient creates a new Mudrex API client
 func NewClient(apiSecret string) *Client {
-	return NewClientWithConfig(apiSecret, "https://trade.mudrex.com/fapi/v1", 30*time.Second)
+	return NewClientWithConfig(apiSecret, DefaultBaseURL, 30*time.Second)
 }
 
 // NewClientWithConfig creates a new Mudrex API client with custom configuration
 func NewClientWithConfig(apiSecret, baseURL string, timeout time.Duration) *Client {
+	return NewClientWithSecretProvider(StaticSecret(apiSecret), baseURL, timeout)
+}
+
+// NewClientWithSecretProvider creates a new Mudrex API client that asks
+// secrets for the API secret before every request
+func NewClientWithSecretProvider(secrets SecretProvider, baseURL string, timeout time.Duration) *Client {
 	httpClient := &http.Client{
 		Timeout: timeout,
 	}
 	
 	client := &Client{
-		apiSecret:   apiSecret,
+		secrets:     secrets,
 		baseURL:     baseURL,
 		timeout:     timeout,
 		httpClient:  httpClient,
@@ -82,45 +106,102 @@ func NewClientWithConfig(apiSecret, baseURL string, timeout time.Duration) *Clie
 	client.Orders = &OrdersAPI{client: client}
 	client.Positions = &PositionsAPI{client: client}
 	client.Fees = &FeesAPI{client: client}
+	client.Market = &MarketAPI{client: client}
 	
 	return client
 }
 
-// doRequest performs an HTTP request with rate limiting and error handling
+// doRequest performs an HTTP request with rate limiting and error handling.
+// If the request fails authentication and the secret provider caches its
+// secret, the cache is invalidated and the request retried once.
 func (c *Client) doRequest(method string, path string, body io.Reader) ([]byte, error) {
+	respBody, _, err := c.doRequestWithHeader(method, path, body, nil)
+	return respBody, err
+}
+
+// doRequestWithHeader is doRequest with extra request headers, also
+// returning the metadata of the last HTTP exchange (nil if none was made)
+func (c *Client) doRequestWithHeader(method string, path string, body io.Reader, header http.Header) ([]byte, *ResponseMeta, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	
+	secret, err := c.secrets.Secret()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get API secret: %w", err)
+	}
+	
+	respBody, meta, err := c.send(method, path, payload, body != nil, secret, header)
+	
+	var authErr *AuthenticationError
+	if invalidator, ok := c.secrets.(Invalidator); ok && errors.As(err, &authErr) {
+		invalidator.Invalidate()
+		if fresh, secretErr := c.secrets.Secret(); secretErr == nil && fresh != secret {
+			return c.send(method, path, payload, body != nil, fresh, header)
+		}
+	}
+	
+	return respBody, meta, err
+}
+
+// send performs a single rate-limited HTTP request
+func (c *Client) send(method, path string, payload []byte, hasBody bool, secret string, header http.Header) ([]byte, *ResponseMeta, error) {
 	// Apply rate limiting
 	c.rateLimiter.Wait()
 	
 	url := c.baseURL + path
 	
+	var body io.Reader
+	if hasBody {
+		body = bytes.NewReader(payload)
+	}
+	
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	
 	// Set headers
-	req.Header.Set("X-Authentication", c.apiSecret)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("X-Authentication", secret)
 	req.Header.Set("Content-Type", "application/json")
 	
 	// Execute request
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		c.observe(newResponseMeta(method, path, nil, time.Since(start)))
+		return nil, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 	
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
+	meta := newResponseMeta(method, path, resp, time.Since(start))
+	c.observe(meta)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, meta, fmt.Errorf("failed to read response: %w", err)
 	}
 	
 	// Check for API errors
 	if err := RaiseForError(resp.StatusCode, respBody); err != nil {
-		return nil, err
+		return nil, meta, err
 	}
 	
-	return respBody, nil
+	return respBody, meta, nil
+}
+
+// observe passes meta to the OnResponse hook, if any
+func (c *Client) observe(meta *ResponseMeta) {
+	if c.onResponse != nil {
+		c.onResponse(meta)
+	}
 }
 
 // Get performs a GET request
@@ -143,6 +224,18 @@ func (c *Client) Delete(path string, body io.Reader) ([]byte, error) {
 	return c.doRequest("DELETE", path, body)
 }
 
+// SetRateLimit replaces the client's rate limiter with one allowing
+// requestsPerSecond requests
+func (c *Client) SetRateLimit(requestsPerSecond float64) {
+	c.rateLimiter = NewRateLimiterWithRate(requestsPerSecond)
+}
+
+// SetTransport replaces the HTTP transport used for API requests,
+// e.g. with a Recorder to record or replay a cassette
+func (c *Client) SetTransport(transport http.RoundTripper) {
+	c.httpClient.Transport = transport
+}
+
 // Close closes the client
 func (c *Client) Close() error {
 	c.httpClient.CloseIdleConnections()
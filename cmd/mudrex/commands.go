@@ -0,0 +1,546 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+var commands = map[string]func(*options, []string) error{
+	"balance":   balanceCmd,
+	"transfer":  transferCmd,
+	"assets":    assetsCmd,
+	"leverage":  leverageCmd,
+	"order":     orderCmd,
+	"orders":    ordersCmd,
+	"positions": positionsCmd,
+	"fees":      feesCmd,
+}
+
+func balanceCmd(opts *options, args []string) error {
+	fs := newFlagSet("balance", opts)
+	args, err := parseFlags(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	which := "all"
+	if len(args) > 0 {
+		which = args[0]
+	}
+	if which != "all" && which != "spot" && which != "futures" {
+		return fmt.Errorf("%w: balance [spot|futures]", errUsage)
+	}
+
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	t := table{Headers: []string{"WALLET", "BALANCE", "LOCKED", "WITHDRAWABLE"}}
+	value := map[string]interface{}{}
+
+	if which != "futures" {
+		spot, err := client.Wallet.GetSpotBalance()
+		if err != nil {
+			return err
+		}
+		value["spot"] = spot
+		t.Rows = append(t.Rows, []string{"SPOT", spot.Total, spot.Invested, spot.Withdrawable})
+	}
+	if which != "spot" {
+		futures, err := client.Wallet.GetFuturesBalance()
+		if err != nil {
+			return err
+		}
+		value["futures"] = futures
+		t.Rows = append(t.Rows, []string{"FUTURES", futures.Balance, futures.LockedAmount, ""})
+	}
+
+	t.Value = value
+	return opts.render(t)
+}
+
+func transferCmd(opts *options, args []string) error {
+	fs := newFlagSet("transfer", opts)
+	args, err := parseFlags(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(args, 2, "transfer to-futures|to-spot <amount>"); err != nil {
+		return err
+	}
+
+	from, to := mudrex.WalletTypeSpot, mudrex.WalletTypeFutures
+	switch args[0] {
+	case "to-futures":
+	case "to-spot":
+		from, to = to, from
+	default:
+		return fmt.Errorf("%w: transfer to-futures|to-spot <amount>", errUsage)
+	}
+
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ok, err := opts.confirm(fmt.Sprintf("transfer %s from %s to %s", args[1], from, to))
+	if err != nil || !ok {
+		return err
+	}
+
+	result, err := client.Wallet.Transfer(from, to, args[1])
+	if err != nil {
+		return err
+	}
+	return opts.render(table{
+		Headers: []string{"TRANSACTION_ID", "SUCCESS"},
+		Rows:    [][]string{{result.TransactionID, strconv.FormatBool(result.Success)}},
+		Value:   result,
+	})
+}
+
+func assetsCmd(opts *options, args []string) error {
+	fs := newFlagSet("assets", opts)
+	page := fs.Int("page", 1, "page number")
+	perPage := fs.Int("per-page", 50, "results per page")
+	sortBy := fs.String("sort-by", "", "sort field")
+	sortOrder := fs.String("sort-order", "", "asc or desc")
+	args, err := parseFlags(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("%w: assets list|get", errUsage)
+	}
+
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var assets []mudrex.Asset
+	switch args[0] {
+	case "list":
+		if assets, err = client.Assets.ListAll(*page, *perPage, *sortBy, *sortOrder); err != nil {
+			return err
+		}
+	case "get":
+		if err := expectArgs(args, 2, "assets get <asset>"); err != nil {
+			return err
+		}
+		asset, err := client.Assets.GetAsset(args[1])
+		if err != nil {
+			return err
+		}
+		assets = []mudrex.Asset{*asset}
+	default:
+		return fmt.Errorf("%w: assets list|get", errUsage)
+	}
+
+	t := table{
+		Headers: []string{"ASSET_ID", "SYMBOL", "MIN_QTY", "MAX_QTY", "QTY_STEP", "MIN_LEV", "MAX_LEV", "MAKER_FEE", "TAKER_FEE", "ACTIVE"},
+		Value:   assets,
+	}
+	for _, a := range assets {
+		t.Rows = append(t.Rows, []string{
+			a.AssetID, a.Symbol, a.MinQuantity, a.MaxQuantity, a.QuantityStep,
+			a.MinLeverage, a.MaxLeverage, a.MakerFee, a.TakerFee, strconv.FormatBool(a.IsActive),
+		})
+	}
+	return opts.render(t)
+}
+
+func leverageCmd(opts *options, args []string) error {
+	fs := newFlagSet("leverage", opts)
+	margin := fs.String("margin", string(mudrex.MarginTypeIsolated), "margin type")
+	args, err := parseFlags(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("%w: leverage get|set", errUsage)
+	}
+
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var leverage *mudrex.Leverage
+	switch args[0] {
+	case "get":
+		if err := expectArgs(args, 2, "leverage get <asset>"); err != nil {
+			return err
+		}
+		if leverage, err = client.Leverage.Get(args[1]); err != nil {
+			return err
+		}
+	case "set":
+		if err := expectArgs(args, 3, "leverage set <asset> <leverage>"); err != nil {
+			return err
+		}
+		ok, err := opts.confirm(fmt.Sprintf("set %s leverage to %sx (%s)", args[1], args[2], *margin))
+		if err != nil || !ok {
+			return err
+		}
+		if leverage, err = client.Leverage.Set(args[1], args[2], mudrex.MarginType(strings.ToUpper(*margin))); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: leverage get|set", errUsage)
+	}
+
+	return opts.render(table{
+		Headers: []string{"ASSET_ID", "LEVERAGE", "MARGIN_TYPE"},
+		Rows:    [][]string{{leverage.AssetID, leverage.Leverage, string(leverage.MarginType)}},
+		Value:   leverage,
+	})
+}
+
+func orderCmd(opts *options, args []string) error {
+	fs := newFlagSet("order", opts)
+	lev := fs.String("lev", "1", "leverage")
+	sl := fs.String("sl", "", "stop loss price")
+	tp := fs.String("tp", "", "take profit price")
+	reduceOnly := fs.Bool("reduce-only", false, "only reduce an existing position")
+	args, err := parseFlags(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("%w: order market|limit", errUsage)
+	}
+
+	req := &mudrex.OrderRequest{Leverage: *lev, ReduceOnly: *reduceOnly}
+	switch args[0] {
+	case "market":
+		if err := expectArgs(args, 4, "order market <asset> long|short <qty>"); err != nil {
+			return err
+		}
+		req.TriggerType = mudrex.TriggerTypeMarket
+	case "limit":
+		if err := expectArgs(args, 5, "order limit <asset> long|short <qty> <price>"); err != nil {
+			return err
+		}
+		req.TriggerType = mudrex.TriggerTypeLimit
+		req.Price = &args[4]
+	default:
+		return fmt.Errorf("%w: order market|limit", errUsage)
+	}
+
+	assetID := args[1]
+	req.Quantity = args[3]
+	switch strings.ToLower(args[2]) {
+	case "long", "buy":
+		req.OrderType = mudrex.OrderTypeLong
+	case "short", "sell":
+		req.OrderType = mudrex.OrderTypeShort
+	default:
+		return fmt.Errorf("%w: side must be long or short", errUsage)
+	}
+	if *sl != "" {
+		req.StopLossPrice = sl
+	}
+	if *tp != "" {
+		req.TakeProfitPrice = tp
+	}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	action := fmt.Sprintf("place %s %s order for %s %s at %sx", req.TriggerType, req.OrderType, req.Quantity, assetID, req.Leverage)
+	if req.Price != nil {
+		action += " @ " + *req.Price
+	}
+	ok, err := opts.confirm(action)
+	if err != nil || !ok {
+		return err
+	}
+
+	order, err := client.Orders.Create(assetID, req)
+	if err != nil {
+		return err
+	}
+	return opts.render(ordersTable([]mudrex.Order{*order}))
+}
+
+func ordersCmd(opts *options, args []string) error {
+	fs := newFlagSet("orders", opts)
+	page := fs.Int("page", 1, "page number")
+	perPage := fs.Int("per-page", 50, "results per page")
+	args, err := parseFlags(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("%w: orders list|get|history|cancel|amend <asset> ...", errUsage)
+	}
+
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	assetID := args[1]
+	var orders []mudrex.Order
+	switch args[0] {
+	case "list":
+		if orders, err = client.Orders.ListOpen(assetID); err != nil {
+			return err
+		}
+	case "history":
+		if orders, err = client.Orders.GetHistory(assetID, *page, *perPage); err != nil {
+			return err
+		}
+	case "get":
+		if err := expectArgs(args, 3, "orders get <asset> <order-id>"); err != nil {
+			return err
+		}
+		order, err := client.Orders.Get(assetID, args[2])
+		if err != nil {
+			return err
+		}
+		orders = []mudrex.Order{*order}
+	case "cancel":
+		if err := expectArgs(args, 3, "orders cancel <asset> <order-id>"); err != nil {
+			return err
+		}
+		ok, err := opts.confirm(fmt.Sprintf("cancel order %s on %s", args[2], assetID))
+		if err != nil || !ok {
+			return err
+		}
+		if _, err := client.Orders.Cancel(assetID, args[2]); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.stdout, "cancelled order %s\n", args[2])
+		return nil
+	case "amend":
+		if err := expectArgs(args, 5, "orders amend <asset> <order-id> <price> <qty>"); err != nil {
+			return err
+		}
+		ok, err := opts.confirm(fmt.Sprintf("amend order %s on %s to %s @ %s", args[2], assetID, args[4], args[3]))
+		if err != nil || !ok {
+			return err
+		}
+		order, err := client.Orders.Amend(assetID, args[2], args[3], args[4])
+		if err != nil {
+			return err
+		}
+		orders = []mudrex.Order{*order}
+	default:
+		return fmt.Errorf("%w: orders list|get|history|cancel|amend", errUsage)
+	}
+
+	return opts.render(ordersTable(orders))
+}
+
+func ordersTable(orders []mudrex.Order) table {
+	t := table{
+		Headers: []string{"ORDER_ID", "SYMBOL", "SIDE", "TYPE", "PRICE", "QTY", "FILLED", "AVG_PRICE", "STATUS", "LEVERAGE", "CREATED_AT"},
+		Value:   orders,
+	}
+	for _, o := range orders {
+		t.Rows = append(t.Rows, []string{
+			o.OrderID, o.Symbol, string(o.OrderType), string(o.TriggerType), o.Price, o.Quantity,
+			o.FilledQuantity, o.AvgFilledPrice, string(o.Status), o.Leverage, formatTime(o.CreatedAt.Time),
+		})
+	}
+	return t
+}
+
+func positionsCmd(opts *options, args []string) error {
+	fs := newFlagSet("positions", opts)
+	page := fs.Int("page", 1, "page number")
+	perPage := fs.Int("per-page", 50, "results per page")
+	qty := fs.String("qty", "", "quantity to close (partial close)")
+	args, err := parseFlags(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("%w: positions list|history|close|reverse|sl|tp", errUsage)
+	}
+
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var positions []mudrex.Position
+	switch args[0] {
+	case "list":
+		if positions, err = client.Positions.ListOpen(); err != nil {
+			return err
+		}
+	case "history":
+		if positions, err = client.Positions.GetHistory(*page, *perPage); err != nil {
+			return err
+		}
+	case "close":
+		if err := expectArgs(args, 2, "positions close <position-id>"); err != nil {
+			return err
+		}
+		action := "close position " + args[1]
+		if *qty != "" {
+			action = fmt.Sprintf("close %s of position %s", *qty, args[1])
+		}
+		ok, err := opts.confirm(action)
+		if err != nil || !ok {
+			return err
+		}
+		if *qty != "" {
+			_, err = client.Positions.ClosePartial(args[1], *qty)
+		} else {
+			_, err = client.Positions.Close(args[1])
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.stdout, "closed position %s\n", args[1])
+		return nil
+	case "reverse":
+		if err := expectArgs(args, 2, "positions reverse <position-id>"); err != nil {
+			return err
+		}
+		ok, err := opts.confirm("reverse position " + args[1])
+		if err != nil || !ok {
+			return err
+		}
+		if _, err := client.Positions.Reverse(args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.stdout, "reversed position %s\n", args[1])
+		return nil
+	case "sl", "tp":
+		if err := expectArgs(args, 3, "positions sl|tp <position-id> <price>"); err != nil {
+			return err
+		}
+		kind := "stop loss"
+		if args[0] == "tp" {
+			kind = "take profit"
+		}
+		ok, err := opts.confirm(fmt.Sprintf("set %s on position %s at %s", kind, args[1], args[2]))
+		if err != nil || !ok {
+			return err
+		}
+		var riskOrder *mudrex.RiskOrder
+		if args[0] == "sl" {
+			riskOrder, err = client.Positions.SetStopLoss(args[1], args[2])
+		} else {
+			riskOrder, err = client.Positions.SetTakeProfit(args[1], args[2])
+		}
+		if err != nil {
+			return err
+		}
+		return opts.render(table{
+			Headers: []string{"ORDER_ID", "POSITION_ID", "TYPE", "TRIGGER_PRICE", "STATUS"},
+			Rows: [][]string{{
+				riskOrder.OrderID, riskOrder.PositionID, string(riskOrder.OrderType), riskOrder.TriggerPrice, riskOrder.Status,
+			}},
+			Value: riskOrder,
+		})
+	default:
+		return fmt.Errorf("%w: positions list|history|close|reverse|sl|tp", errUsage)
+	}
+
+	t := table{
+		Headers: []string{"POSITION_ID", "SYMBOL", "SIDE", "QTY", "ENTRY", "MARK", "LEVERAGE", "MARGIN", "UNREALIZED_PNL", "REALIZED_PNL", "SL", "TP", "STATUS"},
+		Value:   positions,
+	}
+	for _, p := range positions {
+		t.Rows = append(t.Rows, []string{
+			p.PositionID, p.Symbol, string(p.Side), p.Quantity, p.EntryPrice, p.MarkPrice, p.Leverage,
+			p.Margin, p.UnrealizedPnL, p.RealizedPnL, deref(p.StopLoss), deref(p.TakeProfit), string(p.Status),
+		})
+	}
+	return opts.render(t)
+}
+
+func feesCmd(opts *options, args []string) error {
+	fs := newFlagSet("fees", opts)
+	from := fs.String("from", "", "only fees at or after this date (YYYY-MM-DD or RFC 3339)")
+	to := fs.String("to", "", "only fees before this date (YYYY-MM-DD or RFC 3339)")
+	perPage := fs.Int("per-page", 100, "results per page when paging through history")
+	maxPages := fs.Int("max-pages", 100, "maximum pages to fetch; longer history is an error")
+	args, err := parseFlags(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		return fmt.Errorf("%w: fees [--from DATE] [--to DATE]", errUsage)
+	}
+
+	var fromTime, toTime time.Time
+	if *from != "" {
+		if fromTime, err = parseDate(*from); err != nil {
+			return err
+		}
+	}
+	if *to != "" {
+		if toTime, err = parseDate(*to); err != nil {
+			return err
+		}
+	}
+
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	history, err := mudrex.PageAll(*perPage, *maxPages, client.Fees.GetHistory)
+	if err != nil {
+		return err
+	}
+	fees := []mudrex.FeeRecord{}
+	for _, f := range history {
+		if !fromTime.IsZero() && f.CreatedAt.Before(fromTime) {
+			continue
+		}
+		if !toTime.IsZero() && !f.CreatedAt.Before(toTime) {
+			continue
+		}
+		fees = append(fees, f)
+	}
+
+	t := table{
+		Headers: []string{"CREATED_AT", "SYMBOL", "ORDER_ID", "TRADE_TYPE", "FEE_RATE", "FEE_AMOUNT"},
+		Value:   fees,
+	}
+	for _, f := range fees {
+		t.Rows = append(t.Rows, []string{formatTime(f.CreatedAt.Time), f.Symbol, f.OrderID, string(f.TradeType), f.FeeRate, f.FeeAmount})
+	}
+	return opts.render(t)
+}
+
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid date %q", errUsage, s)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,225 @@
+// Command mudrex exposes the Mudrex futures API on the command line.
+//
+// Usage:
+//
+//	mudrex [flags] <command> [subcommand] [args]
+//
+// Credentials come from --secret, or else from a profile in the config file
+// (--config, --profile; see mudrex.LoadConfig) with MUDREX_API_SECRET,
+// MUDREX_BASE_URL and MUDREX_PROFILE overrides. Every command
+// accepts --output table|json|csv. Commands that change account state ask
+// for confirmation unless --yes is given, and only print what they would do
+// with --dry-run.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+const usage = `Usage: mudrex <command> [args] [flags]
+
+Commands:
+  balance [spot|futures]                    Show wallet balances
+  transfer to-futures|to-spot <amount>      Move funds between wallets
+  assets list                               List tradable assets
+  assets get <asset>                        Show an asset
+  leverage get <asset>                      Show leverage settings
+  leverage set <asset> <leverage>           Set leverage (--margin ISOLATED)
+  order market <asset> long|short <qty>     Place a market order (--lev, --sl, --tp)
+  order limit <asset> long|short <qty> <price>
+                                            Place a limit order (--lev, --sl, --tp)
+  orders list <asset>                       List open orders
+  orders get <asset> <order-id>             Show an order
+  orders history <asset>                    Show order history
+  orders cancel <asset> <order-id>          Cancel an order
+  orders amend <asset> <order-id> <price> <qty>
+                                            Amend an order
+  positions list                            List open positions
+  positions history                         Show position history
+  positions close <position-id>             Close a position (--qty for partial)
+  positions reverse <position-id>           Reverse a position
+  positions sl|tp <position-id> <price>     Set stop loss / take profit
+  fees                                      Show fee history (--from, --to)
+
+Global flags:
+  --config string     Config file (default $MUDREX_CONFIG or ~/.mudrex/config.*)
+  --profile string    Profile name (default $MUDREX_PROFILE or the file's default)
+  --secret string     API secret, bypassing the config file
+  --base-url string   API base URL (default from profile, $MUDREX_BASE_URL or production)
+  --timeout duration  Request timeout (default from profile or 30s)
+  --output string     Output format: table, json or csv (default "table")
+  --dry-run           Print state-changing actions instead of performing them
+  --yes               Do not ask for confirmation
+`
+
+// options holds the flags shared by every command
+type options struct {
+	config  string
+	profile string
+	secret  string
+	baseURL string
+	timeout time.Duration
+	output  string
+	dryRun  bool
+	yes     bool
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// errUsage marks errors that should be followed by the usage text
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	opts := &options{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	if err := run(opts, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "mudrex:", err)
+		if errors.Is(err, errUsage) {
+			fmt.Fprint(os.Stderr, "\n"+usage)
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(opts *options, args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(opts.stdout, usage)
+		return nil
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+	return cmd(opts, args[1:])
+}
+
+// newFlagSet returns a flag set with the global flags bound to opts
+func newFlagSet(name string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.config, "config", "", "config file")
+	fs.StringVar(&opts.profile, "profile", "", "profile name")
+	fs.StringVar(&opts.secret, "secret", "", "API secret")
+	fs.StringVar(&opts.baseURL, "base-url", "", "API base URL")
+	fs.DurationVar(&opts.timeout, "timeout", 0, "request timeout")
+	fs.StringVar(&opts.output, "output", "table", "output format")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "print actions instead of performing them")
+	fs.BoolVar(&opts.yes, "yes", false, "do not ask for confirmation")
+	return fs
+}
+
+// parseFlags parses flags that may appear before, between or after
+// positional arguments and returns the positionals. Everything after a "--"
+// terminator is positional, even if it looks like a flag.
+func parseFlags(fs *flag.FlagSet, args []string, opts *options) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		rest := fs.Args()
+		if terminated(fs, args[:len(args)-len(rest)]) {
+			positional = append(positional, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	switch opts.output {
+	case "table", "json", "csv":
+	default:
+		return nil, fmt.Errorf("%w: unknown output format %q", errUsage, opts.output)
+	}
+	return positional, nil
+}
+
+// terminated reports whether parsed, the arguments fs.Parse consumed, ended
+// with a "--" terminator rather than a flag value of "--"
+func terminated(fs *flag.FlagSet, parsed []string) bool {
+	n := len(parsed)
+	if n == 0 || parsed[n-1] != "--" {
+		return false
+	}
+	if n == 1 {
+		return true
+	}
+	name := strings.TrimLeft(parsed[n-2], "-")
+	if !strings.HasPrefix(parsed[n-2], "-") || strings.Contains(name, "=") {
+		return true
+	}
+	f := fs.Lookup(name)
+	if f == nil {
+		return true
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// client builds an API client from the global flags and config profiles
+func (o *options) client() (*mudrex.Client, error) {
+	profile := &mudrex.Profile{APISecret: o.secret}
+	if o.secret == "" {
+		cfg, err := mudrex.LoadConfig(o.config)
+		if err != nil {
+			return nil, err
+		}
+		if profile, err = cfg.Profile(o.profile); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.baseURL != "" {
+		profile.BaseURL = o.baseURL
+	}
+	if o.timeout > 0 {
+		profile.Timeout = mudrex.Duration(o.timeout)
+	}
+	return profile.Client(), nil
+}
+
+// confirm describes a state-changing action and reports whether to proceed.
+// With --dry-run it only prints the action.
+func (o *options) confirm(action string) (bool, error) {
+	if o.dryRun {
+		fmt.Fprintf(o.stdout, "dry run: would %s\n", action)
+		return false, nil
+	}
+	if o.yes {
+		return true, nil
+	}
+
+	fmt.Fprintf(o.stderr, "About to %s. Proceed? [y/N] ", action)
+	line, err := bufio.NewReader(o.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	if answer == "y" || answer == "yes" {
+		return true, nil
+	}
+	fmt.Fprintln(o.stderr, "Aborted.")
+	return false, nil
+}
+
+func expectArgs(args []string, n int, usage string) error {
+	if len(args) != n {
+		return fmt.Errorf("%w: expected %s", errUsage, usage)
+	}
+	return nil
+}
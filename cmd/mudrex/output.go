@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// table is tabular command output. Value is what JSON output encodes.
+type table struct {
+	Headers []string
+	Rows    [][]string
+	Value   interface{}
+}
+
+// render writes t in the selected output format
+func (o *options) render(t table) error {
+	switch o.output {
+	case "json":
+		enc := json.NewEncoder(o.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(t.Value)
+	case "csv":
+		w := csv.NewWriter(o.stdout)
+		if err := w.Write(t.Headers); err != nil {
+			return err
+		}
+		if err := w.WriteAll(t.Rows); err != nil {
+			return err
+		}
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(o.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(t.Headers, "\t"))
+		for _, row := range t.Rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
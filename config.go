@@ -0,0 +1,413 @@
+package mudrex
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables consulted by LoadConfig and Config.Profile
+const (
+	EnvAPISecret = "MUDREX_API_SECRET"
+	EnvBaseURL   = "MUDREX_BASE_URL"
+	EnvProfile   = "MUDREX_PROFILE"
+	EnvConfig    = "MUDREX_CONFIG"
+)
+
+// DefaultBaseURL is the production API endpoint
+const DefaultBaseURL = "https://trade.mudrex.com/fapi/v1"
+
+// DefaultProfileName is used when neither the file nor the environment names a profile
+const DefaultProfileName = "default"
+
+// Profile holds the settings for one account
+type Profile struct {
+	Name      string `json:"-" yaml:"-" toml:"-"`
+	APISecret string `json:"api_secret" yaml:"api_secret" toml:"api_secret"`
+	// APISecretEnv, APISecretFile and APISecretCommand name where to fetch
+	// the secret per request instead of keeping it in the profile
+	APISecretEnv     string        `json:"api_secret_env" yaml:"api_secret_env" toml:"api_secret_env"`
+	APISecretFile    string        `json:"api_secret_file" yaml:"api_secret_file" toml:"api_secret_file"`
+	APISecretCommand SecretCommand `json:"api_secret_command" yaml:"api_secret_command" toml:"api_secret_command"`
+	APISecretTTL     Duration      `json:"api_secret_ttl" yaml:"api_secret_ttl" toml:"api_secret_ttl"`
+	BaseURL          string        `json:"base_url" yaml:"base_url" toml:"base_url"`
+	Timeout          Duration      `json:"timeout" yaml:"timeout" toml:"timeout"`
+	// RateLimit is the maximum requests per second (default 2)
+	RateLimit float64 `json:"rate_limit" yaml:"rate_limit" toml:"rate_limit"`
+}
+
+// Config is a set of named profiles loaded from a file
+type Config struct {
+	DefaultProfile string              `json:"default_profile" yaml:"default_profile" toml:"default_profile"`
+	Profiles       map[string]*Profile `json:"profiles" yaml:"profiles" toml:"profiles"`
+
+	// Path is the file the config was loaded from, if any
+	Path string `json:"-" yaml:"-" toml:"-"`
+}
+
+// SecretCommand is the argv of a secret helper command. It decodes from a
+// list, used as argv verbatim, or from a string, run by the shell so that
+// quoting works as on the command line.
+type SecretCommand []string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (c *SecretCommand) UnmarshalJSON(data []byte) error {
+	var line string
+	if err := json.Unmarshal(data, &line); err == nil {
+		return c.set(line, nil)
+	}
+	var argv []string
+	if err := json.Unmarshal(data, &argv); err != nil {
+		return fmt.Errorf("api_secret_command must be a string or a list of strings")
+	}
+	return c.set("", argv)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (c *SecretCommand) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return c.set(node.Value, nil)
+	}
+	var argv []string
+	if err := node.Decode(&argv); err != nil {
+		return fmt.Errorf("line %d: api_secret_command must be a string or a list of strings", node.Line)
+	}
+	return c.set("", argv)
+}
+
+// UnmarshalTOML implements toml.Unmarshaler
+func (c *SecretCommand) UnmarshalTOML(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return c.set(v, nil)
+	case []interface{}:
+		argv := make([]string, len(v))
+		for i, arg := range v {
+			s, ok := arg.(string)
+			if !ok {
+				return fmt.Errorf("api_secret_command must be a string or a list of strings")
+			}
+			argv[i] = s
+		}
+		return c.set("", argv)
+	}
+	return fmt.Errorf("api_secret_command must be a string or a list of strings")
+}
+
+func (c *SecretCommand) set(line string, argv []string) error {
+	switch {
+	case line != "":
+		if runtime.GOOS == "windows" {
+			*c = SecretCommand{"cmd", "/C", line}
+		} else {
+			*c = SecretCommand{"/bin/sh", "-c", line}
+		}
+	case len(argv) > 0:
+		if argv[0] == "" {
+			return fmt.Errorf("api_secret_command has an empty program name")
+		}
+		*c = argv
+	default:
+		*c = nil
+	}
+	return nil
+}
+
+// Duration is a time.Duration that decodes from "30s"-style strings or
+// numbers of seconds
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return d.setString(s)
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return d.setSeconds(seconds)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: invalid duration", node.Line)
+	}
+	if node.Tag == "!!str" {
+		return d.setString(node.Value)
+	}
+	var seconds float64
+	if err := node.Decode(&seconds); err != nil {
+		return fmt.Errorf("line %d: invalid duration %q", node.Line, node.Value)
+	}
+	return d.setSeconds(seconds)
+}
+
+// UnmarshalTOML implements toml.Unmarshaler
+func (d *Duration) UnmarshalTOML(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return d.setString(v)
+	case int64:
+		return d.setSeconds(float64(v))
+	case float64:
+		return d.setSeconds(v)
+	}
+	return fmt.Errorf("invalid duration %v", value)
+}
+
+func (d *Duration) setString(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d *Duration) setSeconds(seconds float64) error {
+	if math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return fmt.Errorf("invalid duration %v", seconds)
+	}
+	*d = Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// DefaultConfigPaths returns the files LoadConfig looks for when no path is given
+func DefaultConfigPaths() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	dir := filepath.Join(home, ".mudrex")
+	return []string{
+		filepath.Join(dir, "config.toml"),
+		filepath.Join(dir, "config.yaml"),
+		filepath.Join(dir, "config.yml"),
+		filepath.Join(dir, "config.json"),
+	}
+}
+
+// LoadConfig reads profiles from path. The format is chosen by extension:
+// .json, .toml, or .yaml/.yml. When path is empty, MUDREX_CONFIG is used, then
+// the first existing DefaultConfigPaths entry; if none exists an empty config
+// is returned so that environment variables alone can configure a client.
+//
+// Files containing secrets must not be readable by group or others.
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv(EnvConfig)
+	}
+	if path == "" {
+		for _, candidate := range DefaultConfigPaths() {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+	if path == "" {
+		return &Config{Profiles: map[string]*Profile{}}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg Config
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &cfg)
+	case ".toml":
+		_, err = toml.Decode(string(data), &cfg)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*Profile{}
+	}
+	for name, p := range cfg.Profiles {
+		if p == nil {
+			p = &Profile{}
+			cfg.Profiles[name] = p
+		}
+		p.Name = name
+	}
+	cfg.Path = path
+
+	if err := cfg.checkPermissions(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// checkPermissions rejects world- or group-readable files that hold secrets
+func (c *Config) checkPermissions() error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	hasSecret := false
+	for _, p := range c.Profiles {
+		if p.APISecret != "" {
+			hasSecret = true
+		}
+	}
+	if !hasSecret {
+		return nil
+	}
+
+	info, err := os.Stat(c.Path)
+	if err != nil {
+		return fmt.Errorf("failed to stat config: %w", err)
+	}
+	if mode := info.Mode().Perm(); mode&0o077 != 0 {
+		return fmt.Errorf("config %s contains secrets but has mode %04o; restrict it with chmod 600", c.Path, mode)
+	}
+	return nil
+}
+
+// ProfileNames returns the configured profile names in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile resolves a profile with environment overrides applied. An empty
+// name selects MUDREX_PROFILE, then the file's default_profile, then
+// "default". MUDREX_API_SECRET and MUDREX_BASE_URL override the file only
+// when no profile was named, by argument or MUDREX_PROFILE, so a secret
+// exported for one account is never used to sign another's requests.
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = os.Getenv(EnvProfile)
+	}
+	explicit := name != ""
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		name = DefaultProfileName
+	}
+
+	resolved := Profile{Name: name}
+	if p, ok := c.Profiles[name]; ok {
+		resolved = *p
+	} else if explicit || c.DefaultProfile != "" {
+		return nil, fmt.Errorf("profile %q not found", name)
+	}
+
+	if countSecretSources(&resolved) > 1 {
+		return nil, fmt.Errorf("profile %q sets more than one API secret source", name)
+	}
+	if !explicit {
+		if secret := os.Getenv(EnvAPISecret); secret != "" {
+			resolved.APISecret = secret
+		}
+		if baseURL := os.Getenv(EnvBaseURL); baseURL != "" {
+			resolved.BaseURL = baseURL
+		}
+	}
+
+	if countSecretSources(&resolved) == 0 {
+		return nil, fmt.Errorf("profile %q has no API secret (set api_secret, api_secret_env, api_secret_file, api_secret_command or %s)", name, EnvAPISecret)
+	}
+	if resolved.RateLimit < 0 || math.IsNaN(resolved.RateLimit) || math.IsInf(resolved.RateLimit, 0) {
+		return nil, fmt.Errorf("profile %q has invalid rate_limit %v", name, resolved.RateLimit)
+	}
+
+	return &resolved, nil
+}
+
+// countSecretSources counts the secret sources p sets
+func countSecretSources(p *Profile) int {
+	sources := 0
+	for _, set := range []bool{p.APISecret != "", p.APISecretEnv != "", p.APISecretFile != "", len(p.APISecretCommand) > 0} {
+		if set {
+			sources++
+		}
+	}
+	return sources
+}
+
+// Client returns a ready client for the named profile
+func (c *Config) Client(name string) (*Client, error) {
+	p, err := c.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	return p.Client(), nil
+}
+
+// Client builds a client from the profile's settings
+func (p *Profile) Client() *Client {
+	baseURL := p.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	timeout := time.Duration(p.Timeout)
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	client := NewClientWithSecretProvider(p.SecretProvider(), baseURL, timeout)
+	if p.RateLimit > 0 {
+		client.SetRateLimit(p.RateLimit)
+	}
+	return client
+}
+
+// SecretProvider returns the provider for the profile's configured secret
+// source. An inline api_secret (or MUDREX_API_SECRET) takes precedence.
+func (p *Profile) SecretProvider() SecretProvider {
+	switch {
+	case p.APISecret != "":
+		return StaticSecret(p.APISecret)
+	case p.APISecretEnv != "":
+		return EnvSecret(p.APISecretEnv)
+	case p.APISecretFile != "":
+		return NewFileSecret(p.APISecretFile)
+	case len(p.APISecretCommand) > 0:
+		return NewCommandSecret(time.Duration(p.APISecretTTL), p.APISecretCommand[0], p.APISecretCommand[1:]...)
+	}
+	return StaticSecret("")
+}
+
+// LoadClient loads the config at path (see LoadConfig) and returns a client
+// for the named profile (see Config.Profile)
+func LoadClient(path, profile string) (*Client, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Client(profile)
+}
@@ -0,0 +1,259 @@
+package mudrex
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// writeConfig writes content to name in a fresh directory with owner-only
+// permissions
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clearConfigEnv unsets the environment variables Config.Profile consults
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{EnvAPISecret, EnvBaseURL, EnvProfile, EnvConfig} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	want := map[string]Profile{
+		"default": {
+			Name:      "default",
+			APISecret: "1234_5678",
+			Timeout:   Duration(15 * time.Second),
+			RateLimit: 1.5,
+		},
+		"prod": {
+			Name:             "prod",
+			APISecretCommand: SecretCommand{"pass", "show", "mudrex/prod key"},
+			APISecretTTL:     Duration(90 * time.Second),
+			BaseURL:          "https://example.com/fapi/v1",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "JSON",
+			file: "config.json",
+			content: `{
+				"default_profile": "default",
+				"profiles": {
+					"default": {"api_secret": "1234_5678", "timeout": "15s", "rate_limit": 1.5},
+					"prod": {
+						"api_secret_command": ["pass", "show", "mudrex/prod key"],
+						"api_secret_ttl": 90,
+						"base_url": "https://example.com/fapi/v1"
+					}
+				}
+			}`,
+		},
+		{
+			name: "TOML",
+			file: "config.toml",
+			content: `
+default_profile = "default"
+
+[profiles.default]
+api_secret = "1234_5678" # comment
+timeout = "15s"
+rate_limit = 1.5
+
+[profiles.prod]
+api_secret_command = ["pass", "show", "mudrex/prod key"]
+api_secret_ttl = 90
+base_url = "https://example.com/fapi/v1"
+`,
+		},
+		{
+			name: "YAML",
+			file: "config.yaml",
+			content: `
+default_profile: default
+profiles:
+  default:
+    api_secret: 1234_5678 # unquoted, kept as written
+    timeout: 15s
+    rate_limit: 1.5
+  prod:
+    api_secret_command: [pass, show, "mudrex/prod key"]
+    api_secret_ttl: 90
+    base_url: https://example.com/fapi/v1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(writeConfig(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if cfg.DefaultProfile != "default" {
+				t.Errorf("DefaultProfile = %q, want default", cfg.DefaultProfile)
+			}
+			if names := cfg.ProfileNames(); !reflect.DeepEqual(names, []string{"default", "prod"}) {
+				t.Fatalf("ProfileNames() = %v", names)
+			}
+			for name, p := range want {
+				if got := *cfg.Profiles[name]; !reflect.DeepEqual(got, p) {
+					t.Errorf("profile %s = %+v, want %+v", name, got, p)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"unknown extension", "config.ini", "api_secret = x"},
+		{"bad duration", "config.json", `{"profiles": {"a": {"timeout": "soon"}}}`},
+		{"bad command type", "config.toml", "[profiles.a]\napi_secret_command = 3"},
+		{"empty program name", "config.yaml", "profiles:\n  a:\n    api_secret_command: ['', x]"},
+		{"malformed TOML", "config.toml", "[profiles.a\napi_secret = \"x\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadConfig(writeConfig(t, tt.file, tt.content)); err == nil {
+				t.Error("LoadConfig succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestLoadConfigPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not checked on Windows")
+	}
+	path := writeConfig(t, "config.json", `{"profiles": {"a": {"api_secret": "x"}}}`)
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig of a group-readable file with a secret succeeded, want error")
+	}
+}
+
+func TestSecretCommandShellLine(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, "config.yaml", "profiles:\n  a:\n    api_secret_command: echo \"my key\"\n"))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	want := SecretCommand{"/bin/sh", "-c", `echo "my key"`}
+	if runtime.GOOS == "windows" {
+		want = SecretCommand{"cmd", "/C", `echo "my key"`}
+	}
+	if got := cfg.Profiles["a"].APISecretCommand; !reflect.DeepEqual(got, want) {
+		t.Errorf("APISecretCommand = %q, want %q", got, want)
+	}
+}
+
+func TestConfigProfile(t *testing.T) {
+	cfg := &Config{
+		DefaultProfile: "main",
+		Profiles: map[string]*Profile{
+			"main":    {Name: "main", APISecret: "main-secret"},
+			"prod":    {Name: "prod", APISecretEnv: "PROD_SECRET"},
+			"empty":   {Name: "empty"},
+			"both":    {Name: "both", APISecret: "x", APISecretFile: "/tmp/secret"},
+			"limited": {Name: "limited", APISecret: "x", RateLimit: -1},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		profile    string
+		env        map[string]string
+		wantSecret string
+		wantEnv    string
+		wantURL    string
+		wantErr    bool
+	}{
+		{name: "default profile", wantSecret: "main-secret"},
+		{name: "named profile", profile: "prod", wantEnv: "PROD_SECRET"},
+		{name: "MUDREX_PROFILE", env: map[string]string{EnvProfile: "prod"}, wantEnv: "PROD_SECRET"},
+		{
+			name:       "env overrides the default profile",
+			env:        map[string]string{EnvAPISecret: "env-secret", EnvBaseURL: "https://env.example"},
+			wantSecret: "env-secret",
+			wantURL:    "https://env.example",
+		},
+		{
+			name:    "env never overrides a named profile",
+			profile: "prod",
+			env:     map[string]string{EnvAPISecret: "env-secret", EnvBaseURL: "https://env.example"},
+			wantEnv: "PROD_SECRET",
+		},
+		{
+			name:    "env never overrides a profile named by MUDREX_PROFILE",
+			env:     map[string]string{EnvProfile: "prod", EnvAPISecret: "env-secret"},
+			wantEnv: "PROD_SECRET",
+		},
+		{name: "missing profile", profile: "nope", wantErr: true},
+		{name: "no secret source", profile: "empty", wantErr: true},
+		{
+			name:    "env secret does not fill a named profile",
+			profile: "empty",
+			env:     map[string]string{EnvAPISecret: "env-secret"},
+			wantErr: true,
+		},
+		{name: "two secret sources", profile: "both", wantErr: true},
+		{name: "negative rate limit", profile: "limited", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			p, err := cfg.Profile(tt.profile)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Profile(%q) = %+v, want error", tt.profile, p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Profile(%q): %v", tt.profile, err)
+			}
+			if p.APISecret != tt.wantSecret || p.APISecretEnv != tt.wantEnv || p.BaseURL != tt.wantURL {
+				t.Errorf("Profile(%q) = secret %q, env %q, URL %q; want %q, %q, %q",
+					tt.profile, p.APISecret, p.APISecretEnv, p.BaseURL, tt.wantSecret, tt.wantEnv, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestConfigProfileWithoutFile(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv(EnvAPISecret, "env-secret")
+
+	cfg := &Config{Profiles: map[string]*Profile{}}
+	p, err := cfg.Profile("")
+	if err != nil {
+		t.Fatalf("Profile: %v", err)
+	}
+	if p.Name != DefaultProfileName || p.APISecret != "env-secret" {
+		t.Errorf("Profile = %+v, want %s with the env secret", p, DefaultProfileName)
+	}
+}
@@ -0,0 +1,20 @@
+package mudrex
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ParseDecimal parses one of the API's decimal string fields, such as a
+// price or quantity, treating an empty string as zero. The error names field.
+func ParseDecimal(field, value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, validationError(fmt.Sprintf("invalid %s %q", field, value))
+	}
+	return f, nil
+}
@@ -0,0 +1,261 @@
+package mudrex
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// RiskOrderType distinguishes stop loss from take profit orders
+type RiskOrderType string
+
+// TradeType tells whether a fill added (maker) or removed (taker) liquidity
+type TradeType string
+
+const (
+	// Risk order types
+	RiskOrderTypeStopLoss   RiskOrderType = "STOP_LOSS"
+	RiskOrderTypeTakeProfit RiskOrderType = "TAKE_PROFIT"
+
+	// Trade types
+	TradeTypeMaker TradeType = "MAKER"
+	TradeTypeTaker TradeType = "TAKER"
+)
+
+var (
+	orderTypes       = []OrderType{OrderTypeLong, OrderTypeShort}
+	triggerTypes     = []TriggerType{TriggerTypeMarket, TriggerTypeLimit}
+	marginTypes      = []MarginType{MarginTypeIsolated}
+	orderStatuses    = []OrderStatus{OrderStatusOpen, OrderStatusFilled, OrderStatusPartiallyFilled, OrderStatusCancelled, OrderStatusExpired}
+	positionStatuses = []PositionStatus{PositionStatusOpen, PositionStatusClosed, PositionStatusLiquidated}
+	walletTypes      = []WalletType{WalletTypeSpot, WalletTypeFutures}
+	riskOrderTypes   = []RiskOrderType{RiskOrderTypeStopLoss, RiskOrderTypeTakeProfit}
+	tradeTypes       = []TradeType{TradeTypeMaker, TradeTypeTaker}
+)
+
+var (
+	unknownEnumMu      sync.RWMutex
+	unknownEnumHandler func(enum, value string)
+)
+
+// SetUnknownEnumHandler registers fn to be called whenever a response
+// contains an enum value this version of the SDK does not know. Decoding
+// never fails on unknown values; they are kept verbatim and report
+// Valid() == false. Pass nil to remove the handler.
+func SetUnknownEnumHandler(fn func(enum, value string)) {
+	unknownEnumMu.Lock()
+	unknownEnumHandler = fn
+	unknownEnumMu.Unlock()
+}
+
+func (t OrderType) String() string      { return string(t) }
+func (t TriggerType) String() string    { return string(t) }
+func (t MarginType) String() string     { return string(t) }
+func (s OrderStatus) String() string    { return string(s) }
+func (s PositionStatus) String() string { return string(s) }
+func (t WalletType) String() string     { return string(t) }
+func (t RiskOrderType) String() string  { return string(t) }
+func (t TradeType) String() string      { return string(t) }
+
+// Valid reports whether t is a known order type
+func (t OrderType) Valid() bool { return isKnown(t, orderTypes) }
+
+// Valid reports whether t is a known trigger type
+func (t TriggerType) Valid() bool { return isKnown(t, triggerTypes) }
+
+// Valid reports whether t is a known margin type
+func (t MarginType) Valid() bool { return isKnown(t, marginTypes) }
+
+// Valid reports whether s is a known order status
+func (s OrderStatus) Valid() bool { return isKnown(s, orderStatuses) }
+
+// Valid reports whether s is a known position status
+func (s PositionStatus) Valid() bool { return isKnown(s, positionStatuses) }
+
+// Valid reports whether t is a known wallet type
+func (t WalletType) Valid() bool { return isKnown(t, walletTypes) }
+
+// Valid reports whether t is a known risk order type
+func (t RiskOrderType) Valid() bool { return isKnown(t, riskOrderTypes) }
+
+// Valid reports whether t is a known trade type
+func (t TradeType) Valid() bool { return isKnown(t, tradeTypes) }
+
+// ParseOrderType parses s case-insensitively
+func ParseOrderType(s string) (OrderType, error) { return parseEnum("order type", s, orderTypes) }
+
+// ParseTriggerType parses s case-insensitively
+func ParseTriggerType(s string) (TriggerType, error) {
+	return parseEnum("trigger type", s, triggerTypes)
+}
+
+// ParseMarginType parses s case-insensitively
+func ParseMarginType(s string) (MarginType, error) { return parseEnum("margin type", s, marginTypes) }
+
+// ParseOrderStatus parses s case-insensitively
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	return parseEnum("order status", s, orderStatuses)
+}
+
+// ParsePositionStatus parses s case-insensitively
+func ParsePositionStatus(s string) (PositionStatus, error) {
+	return parseEnum("position status", s, positionStatuses)
+}
+
+// ParseWalletType parses s case-insensitively
+func ParseWalletType(s string) (WalletType, error) { return parseEnum("wallet type", s, walletTypes) }
+
+// ParseRiskOrderType parses s case-insensitively
+func ParseRiskOrderType(s string) (RiskOrderType, error) {
+	return parseEnum("risk order type", s, riskOrderTypes)
+}
+
+// ParseTradeType parses s case-insensitively
+func ParseTradeType(s string) (TradeType, error) { return parseEnum("trade type", s, tradeTypes) }
+
+func (t *OrderType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, "order type", orderTypes, t)
+}
+
+func (t *TriggerType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, "trigger type", triggerTypes, t)
+}
+
+func (t *MarginType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, "margin type", marginTypes, t)
+}
+
+func (s *OrderStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, "order status", orderStatuses, s)
+}
+
+func (s *PositionStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, "position status", positionStatuses, s)
+}
+
+func (t *WalletType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, "wallet type", walletTypes, t)
+}
+
+func (t *RiskOrderType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, "risk order type", riskOrderTypes, t)
+}
+
+func (t *TradeType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, "trade type", tradeTypes, t)
+}
+
+// Validate checks the request before it is sent: known order and trigger
+// types, positive quantity and leverage, a price for limit orders, and stop
+// loss / take profit on the correct side of a limit price
+func (r *OrderRequest) Validate() error {
+	var problems []string
+	if !r.OrderType.Valid() {
+		problems = append(problems, fmt.Sprintf("invalid order type %q", r.OrderType))
+	}
+	if !r.TriggerType.Valid() {
+		problems = append(problems, fmt.Sprintf("invalid trigger type %q", r.TriggerType))
+	}
+	if _, err := positiveDecimal("quantity", r.Quantity); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := positiveDecimal("leverage", r.Leverage); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	var price float64
+	if r.TriggerType == TriggerTypeLimit {
+		if r.Price == nil {
+			problems = append(problems, "price is required for LIMIT orders")
+		} else if p, err := positiveDecimal("price", *r.Price); err != nil {
+			problems = append(problems, err.Error())
+		} else {
+			price = p
+		}
+	}
+
+	if r.StopLossPrice != nil {
+		sl, err := positiveDecimal("stoploss_price", *r.StopLossPrice)
+		switch {
+		case err != nil:
+			problems = append(problems, err.Error())
+		case price > 0 && r.OrderType == OrderTypeLong && sl >= price:
+			problems = append(problems, "stop loss must be below the price for LONG orders")
+		case price > 0 && r.OrderType == OrderTypeShort && sl <= price:
+			problems = append(problems, "stop loss must be above the price for SHORT orders")
+		}
+	}
+	if r.TakeProfitPrice != nil {
+		tp, err := positiveDecimal("takeprofit_price", *r.TakeProfitPrice)
+		switch {
+		case err != nil:
+			problems = append(problems, err.Error())
+		case price > 0 && r.OrderType == OrderTypeLong && tp <= price:
+			problems = append(problems, "take profit must be above the price for LONG orders")
+		case price > 0 && r.OrderType == OrderTypeShort && tp >= price:
+			problems = append(problems, "take profit must be below the price for SHORT orders")
+		}
+	}
+
+	if len(problems) > 0 {
+		return validationError(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func isKnown[T ~string](value T, known []T) bool {
+	for _, k := range known {
+		if value == k {
+			return true
+		}
+	}
+	return false
+}
+
+func parseEnum[T ~string](name, s string, known []T) (T, error) {
+	normalized := T(strings.ToUpper(strings.TrimSpace(s)))
+	if isKnown(normalized, known) {
+		return normalized, nil
+	}
+	return "", validationError(fmt.Sprintf("unknown %s %q", name, s))
+}
+
+// unmarshalEnum decodes a JSON string into dst, normalizing the case of known
+// values and keeping unknown values verbatim after reporting them
+func unmarshalEnum[T ~string](data []byte, name string, known []T, dst *T) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%s must be a string: %w", name, err)
+	}
+	if s == "" {
+		*dst = ""
+		return nil
+	}
+	if value, err := parseEnum(name, s, known); err == nil {
+		*dst = value
+		return nil
+	}
+
+	*dst = T(s)
+	unknownEnumMu.RLock()
+	handler := unknownEnumHandler
+	unknownEnumMu.RUnlock()
+	if handler != nil {
+		handler(name, s)
+	}
+	return nil
+}
+
+// positiveDecimal parses a finite numeric string that must be greater than
+// zero
+func positiveDecimal(field, value string) (float64, error) {
+	f, err := ParseDecimal(field, value)
+	if err != nil || value == "" {
+		return 0, fmt.Errorf("invalid %s %q", field, value)
+	}
+	if f <= 0 {
+		return 0, fmt.Errorf("%s must be positive", field)
+	}
+	return f, nil
+}
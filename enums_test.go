@@ -0,0 +1,53 @@
+package mudrex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrderRequestValidate(t *testing.T) {
+	str := func(s string) *string { return &s }
+	valid := func() *OrderRequest {
+		return &OrderRequest{
+			Leverage:    "10",
+			Quantity:    "0.5",
+			OrderType:   OrderTypeLong,
+			TriggerType: TriggerTypeLimit,
+			Price:       str("100"),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *OrderRequest)
+		want   string
+	}{
+		{name: "valid", modify: func(*OrderRequest) {}},
+		{name: "NaN quantity", modify: func(r *OrderRequest) { r.Quantity = "NaN" }, want: `invalid quantity "NaN"`},
+		{name: "infinite price", modify: func(r *OrderRequest) { r.Price = str("Inf") }, want: `invalid price "Inf"`},
+		{name: "overflowing leverage", modify: func(r *OrderRequest) { r.Leverage = "1e400" }, want: `invalid leverage "1e400"`},
+		{name: "empty quantity", modify: func(r *OrderRequest) { r.Quantity = "" }, want: `invalid quantity ""`},
+		{name: "zero quantity", modify: func(r *OrderRequest) { r.Quantity = "0" }, want: "quantity must be positive"},
+		{name: "negative stop loss", modify: func(r *OrderRequest) { r.StopLossPrice = str("-1") }, want: "stoploss_price must be positive"},
+		{name: "NaN take profit", modify: func(r *OrderRequest) { r.TakeProfitPrice = str("nan") }, want: `invalid takeprofit_price "nan"`},
+		{name: "limit without price", modify: func(r *OrderRequest) { r.Price = nil }, want: "price is required"},
+		{name: "stop loss above a long", modify: func(r *OrderRequest) { r.StopLossPrice = str("101") }, want: "stop loss must be below"},
+		{name: "take profit above a short", modify: func(r *OrderRequest) {
+			r.OrderType = OrderTypeShort
+			r.TakeProfitPrice = str("101")
+		}, want: "take profit must be below"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.modify(r)
+			err := r.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Validate: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Validate error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
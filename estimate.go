@@ -0,0 +1,205 @@
+package mudrex
+
+import (
+	"fmt"
+	"math"
+)
+
+// OrderEstimate is the projected cost of an order, computed locally from
+// asset metadata and the futures balance
+type OrderEstimate struct {
+	AssetID  string    `json:"asset_id"`
+	Side     OrderType `json:"side"`
+	Quantity float64   `json:"quantity"`
+	Price    float64   `json:"price"`
+	Leverage float64   `json:"leverage"`
+	// Notional is quantity times price
+	Notional float64 `json:"notional"`
+	// InitialMargin is the notional divided by leverage
+	InitialMargin float64 `json:"initial_margin"`
+	// MakerFee and TakerFee are the fees at the asset's advertised rates
+	MakerFee float64 `json:"maker_fee"`
+	TakerFee float64 `json:"taker_fee"`
+	// Fee is the expected fee: taker for market orders, maker for limit orders
+	Fee float64 `json:"fee"`
+	// RequiredBalance is the initial margin plus the expected fee
+	RequiredBalance float64 `json:"required_balance"`
+	// LiquidationPrice is the estimated isolated-margin liquidation price
+	LiquidationPrice float64 `json:"liquidation_price"`
+	// AvailableBalance is the futures balance less the locked amount; it is
+	// zero when no balance was supplied
+	AvailableBalance float64 `json:"available_balance"`
+	Sufficient       bool    `json:"sufficient"`
+	Shortfall        float64 `json:"shortfall"`
+	// Warnings list asset limits the order would violate
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// EstimateParams describes the order to estimate
+type EstimateParams struct {
+	Side     OrderType
+	Quantity float64
+	Price    float64
+	Leverage float64
+	// TriggerType selects the expected fee (default TriggerTypeMarket)
+	TriggerType TriggerType
+	// MaintenanceMarginRate is the fraction of notional kept as maintenance
+	// margin when estimating the liquidation price (default 0)
+	MaintenanceMarginRate float64
+}
+
+// Estimate computes the margin, fees and liquidation price of an order on
+// asset. When balance is nil the balance check is skipped.
+func Estimate(asset *Asset, p EstimateParams, balance *FuturesBalance) (*OrderEstimate, error) {
+	if p.Side != OrderTypeLong && p.Side != OrderTypeShort {
+		return nil, validationError(fmt.Sprintf("side must be %s or %s", OrderTypeLong, OrderTypeShort))
+	}
+	if p.Quantity <= 0 {
+		return nil, validationError("quantity must be positive")
+	}
+	if p.Price <= 0 {
+		return nil, validationError("price must be positive")
+	}
+	if p.Leverage <= 0 {
+		return nil, validationError("leverage must be positive")
+	}
+	if p.MaintenanceMarginRate < 0 || p.MaintenanceMarginRate >= 1 {
+		return nil, validationError("maintenance margin rate must be in [0, 1)")
+	}
+
+	makerRate, err := ParseDecimal("maker_fee", asset.MakerFee)
+	if err != nil {
+		return nil, err
+	}
+	takerRate, err := ParseDecimal("taker_fee", asset.TakerFee)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &OrderEstimate{
+		AssetID:  asset.AssetID,
+		Side:     p.Side,
+		Quantity: p.Quantity,
+		Price:    p.Price,
+		Leverage: p.Leverage,
+	}
+	e.Notional = p.Quantity * p.Price
+	e.InitialMargin = e.Notional / p.Leverage
+	e.MakerFee = e.Notional * makerRate
+	e.TakerFee = e.Notional * takerRate
+	e.Fee = e.TakerFee
+	if p.TriggerType == TriggerTypeLimit {
+		e.Fee = e.MakerFee
+	}
+	e.RequiredBalance = e.InitialMargin + e.Fee
+
+	if p.Side == OrderTypeLong {
+		e.LiquidationPrice = p.Price * (1 - 1/p.Leverage + p.MaintenanceMarginRate)
+	} else {
+		e.LiquidationPrice = p.Price * (1 + 1/p.Leverage - p.MaintenanceMarginRate)
+	}
+	e.LiquidationPrice = math.Max(e.LiquidationPrice, 0)
+
+	e.Warnings, err = assetLimitWarnings(asset, p.Quantity, p.Leverage)
+	if err != nil {
+		return nil, err
+	}
+
+	if balance != nil {
+		total, err := ParseDecimal("balance", balance.Balance)
+		if err != nil {
+			return nil, err
+		}
+		locked, err := ParseDecimal("locked_amount", balance.LockedAmount)
+		if err != nil {
+			return nil, err
+		}
+		e.AvailableBalance = total - locked
+		e.Sufficient = e.AvailableBalance >= e.RequiredBalance
+		if !e.Sufficient {
+			e.Shortfall = e.RequiredBalance - e.AvailableBalance
+		}
+	}
+
+	return e, nil
+}
+
+// EstimateRequest estimates an OrderRequest. The request's price is used for
+// limit orders; market orders are estimated at marketPrice.
+func EstimateRequest(asset *Asset, req *OrderRequest, marketPrice float64, balance *FuturesBalance) (*OrderEstimate, error) {
+	quantity, err := ParseDecimal("quantity", req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	leverage, err := ParseDecimal("leverage", req.Leverage)
+	if err != nil {
+		return nil, err
+	}
+
+	price := marketPrice
+	if req.TriggerType == TriggerTypeLimit {
+		if req.Price == nil {
+			return nil, validationError("limit order requires a price")
+		}
+		if price, err = ParseDecimal("price", *req.Price); err != nil {
+			return nil, err
+		}
+	}
+
+	return Estimate(asset, EstimateParams{
+		Side:        req.OrderType,
+		Quantity:    quantity,
+		Price:       price,
+		Leverage:    leverage,
+		TriggerType: req.TriggerType,
+	}, balance)
+}
+
+// EstimateOrder fetches the asset and futures balance and estimates req
+// without calling the order endpoint. Market orders are estimated at
+// marketPrice.
+func (c *Client) EstimateOrder(assetID string, req *OrderRequest, marketPrice float64) (*OrderEstimate, error) {
+	asset, err := c.Assets.GetAsset(assetID)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := c.Wallet.GetFuturesBalance()
+	if err != nil {
+		return nil, err
+	}
+	return EstimateRequest(asset, req, marketPrice, balance)
+}
+
+// assetLimitWarnings describes quantity and leverage outside the asset's limits
+func assetLimitWarnings(asset *Asset, quantity, leverage float64) ([]string, error) {
+	var warnings []string
+	check := func(name string, value float64, minField, min, maxField, max string) error {
+		lo, err := ParseDecimal(minField, min)
+		if err != nil {
+			return err
+		}
+		hi, err := ParseDecimal(maxField, max)
+		if err != nil {
+			return err
+		}
+		if lo > 0 && value < lo {
+			warnings = append(warnings, fmt.Sprintf("%s %v is below the minimum %s", name, value, min))
+		}
+		if hi > 0 && value > hi {
+			warnings = append(warnings, fmt.Sprintf("%s %v is above the maximum %s", name, value, max))
+		}
+		return nil
+	}
+
+	if err := check("quantity", quantity, "min_quantity", asset.MinQuantity, "max_quantity", asset.MaxQuantity); err != nil {
+		return nil, err
+	}
+	if err := check("leverage", leverage, "min_leverage", asset.MinLeverage, "max_leverage", asset.MaxLeverage); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+func validationError(msg string) error {
+	return &ValidationError{&MudrexError{Code: 400, Message: msg, Status: 400}}
+}
@@ -0,0 +1,412 @@
+// Package feestats aggregates fee history by asset, period and trade type,
+// and flags fees charged at rates that differ from the advertised schedule.
+package feestats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// Bucket is the time granularity of a grouping
+type Bucket string
+
+const (
+	// BucketNone groups all records regardless of time
+	BucketNone  Bucket = ""
+	BucketDay   Bucket = "DAY"
+	BucketWeek  Bucket = "WEEK"
+	BucketMonth Bucket = "MONTH"
+)
+
+// DefaultTolerance is the relative deviation from the advertised rate
+// tolerated before a fee is flagged
+const DefaultTolerance = 0.05
+
+// Query selects how records are grouped and filtered
+type Query struct {
+	ByAsset     bool
+	ByTradeType bool
+	Bucket      Bucket
+	// From and To, when set, restrict records to [From, To)
+	From time.Time
+	To   time.Time
+	// Location for day, week and month boundaries (default UTC)
+	Location *time.Location
+	// Tolerance is the relative rate deviation flagged as an anomaly
+	// (default DefaultTolerance)
+	Tolerance float64
+	// PerPage and MaxPages control paging in Fetch (defaults
+	// mudrex.DefaultPerPage and mudrex.DefaultMaxPages). Fetch fails with
+	// mudrex.ErrPageLimit rather than analyze a truncated history.
+	PerPage  int
+	MaxPages int
+}
+
+// Group is the aggregate of the records sharing a key. Fields that are not
+// part of the query's grouping are empty.
+type Group struct {
+	AssetID   string           `json:"asset_id,omitempty"`
+	Symbol    string           `json:"symbol,omitempty"`
+	TradeType mudrex.TradeType `json:"trade_type,omitempty"`
+	Period    string           `json:"period,omitempty"`
+	Start     time.Time        `json:"start"`
+
+	Count      int     `json:"count"`
+	MakerCount int     `json:"maker_count"`
+	TakerCount int     `json:"taker_count"`
+	TotalFees  float64 `json:"total_fees"`
+	MakerFees  float64 `json:"maker_fees"`
+	TakerFees  float64 `json:"taker_fees"`
+	// Notional is the filled quantity times average price of the orders
+	// the records belong to. An order's notional is split across its
+	// records in proportion to their fees.
+	Notional float64 `json:"notional"`
+	// Unpriced counts records whose order is unknown or unfilled. They are
+	// left out of Notional, EffectiveRate, ExpectedFees and Excess.
+	Unpriced int `json:"unpriced"`
+	// MakerRatio is the maker share of notional, or of record count when no
+	// notional is known
+	MakerRatio float64 `json:"maker_ratio"`
+	// EffectiveRate is the fees of priced records divided by notional
+	EffectiveRate float64 `json:"effective_rate"`
+	// ExpectedFees is what the notional would cost at the advertised asset
+	// rates; records for assets without metadata are excluded
+	ExpectedFees float64 `json:"expected_fees"`
+	// Excess is fees paid above the advertised rates, over the same records
+	// as ExpectedFees
+	Excess float64 `json:"excess"`
+
+	makerNotional float64
+	pricedFees    float64
+}
+
+// Anomaly is a fee record whose rate does not match the asset's advertised
+// fee for its trade type
+type Anomaly struct {
+	Record         mudrex.FeeRecord `json:"record"`
+	Reason         string           `json:"reason"`
+	ChargedRate    float64          `json:"charged_rate"`
+	AdvertisedRate float64          `json:"advertised_rate"`
+}
+
+// Analysis is the result of a query
+type Analysis struct {
+	// Groups are ordered by period, then asset, then trade type
+	Groups    []Group   `json:"groups"`
+	Total     Group     `json:"total"`
+	Anomalies []Anomaly `json:"anomalies"`
+}
+
+// Fetch pages through the fee history, looks up every asset seen along with
+// its order history and analyzes the records
+func Fetch(client *mudrex.Client, q Query) (*Analysis, error) {
+	records, err := mudrex.PageAll(q.PerPage, q.MaxPages, client.Fees.GetHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fee history: %w", err)
+	}
+
+	var (
+		assets []mudrex.Asset
+		orders []mudrex.Order
+	)
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if r.AssetID == "" || seen[r.AssetID] {
+			continue
+		}
+		seen[r.AssetID] = true
+		asset, err := client.Assets.GetAsset(r.AssetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get asset %s: %w", r.AssetID, err)
+		}
+		assets = append(assets, *asset)
+
+		assetID := r.AssetID
+		assetOrders, err := mudrex.PageAll(q.PerPage, q.MaxPages, func(page, perPage int) ([]mudrex.Order, error) {
+			return client.Orders.GetHistory(assetID, page, perPage)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch order history for %s: %w", assetID, err)
+		}
+		orders = append(orders, assetOrders...)
+	}
+
+	return Analyze(records, orders, assets, q)
+}
+
+// Analyze groups records according to q and compares their rates with the
+// MakerFee and TakerFee advertised on assets. Records are linked to orders by
+// FeeRecord.OrderID to find the notional they were charged on.
+func Analyze(records []mudrex.FeeRecord, orders []mudrex.Order, assets []mudrex.Asset, q Query) (*Analysis, error) {
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	if q.Tolerance <= 0 {
+		q.Tolerance = DefaultTolerance
+	}
+	switch q.Bucket {
+	case BucketNone, BucketDay, BucketWeek, BucketMonth:
+	default:
+		return nil, fmt.Errorf("unknown bucket %q", q.Bucket)
+	}
+
+	byAsset := make(map[string]mudrex.Asset, len(assets))
+	for _, a := range assets {
+		byAsset[a.AssetID] = a
+	}
+	notionals, err := recordNotionals(records, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Analysis{}
+	groups := make(map[groupKey]*Group)
+
+	for i, r := range records {
+		if (!q.From.IsZero() && r.CreatedAt.Before(q.From)) || (!q.To.IsZero() && !r.CreatedAt.Before(q.To)) {
+			continue
+		}
+
+		amount, err := mudrex.ParseDecimal("fee_amount", r.FeeAmount)
+		if err != nil {
+			return nil, fmt.Errorf("fee for order %s: %w", r.OrderID, err)
+		}
+		rate, err := mudrex.ParseDecimal("fee_rate", r.FeeRate)
+		if err != nil {
+			return nil, fmt.Errorf("fee for order %s: %w", r.OrderID, err)
+		}
+		tradeType := r.TradeType
+		notional, priced := notionals[i]
+
+		expected, known := 0.0, false
+		if asset, ok := byAsset[r.AssetID]; ok {
+			advertised, ok, err := advertisedRate(asset, tradeType)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				known = priced
+				expected = notional * advertised
+				if anomaly := check(r, tradeType, rate, advertised, q.Tolerance); anomaly != nil {
+					a.Anomalies = append(a.Anomalies, *anomaly)
+				}
+			}
+		}
+		if !tradeType.Valid() {
+			a.Anomalies = append(a.Anomalies, Anomaly{
+				Record:      r,
+				Reason:      fmt.Sprintf("unknown trade type %q", r.TradeType),
+				ChargedRate: rate,
+			})
+		}
+
+		key := groupKey{}
+		if q.ByAsset {
+			key.assetID = r.AssetID
+		}
+		if q.ByTradeType {
+			key.tradeType = tradeType
+		}
+		if q.Bucket != BucketNone {
+			key.start, key.period = bucketOf(r.CreatedAt.Time, q.Bucket, q.Location)
+		}
+
+		g, ok := groups[key]
+		if !ok {
+			g = &Group{AssetID: key.assetID, TradeType: key.tradeType, Period: key.period, Start: key.start}
+			groups[key] = g
+		}
+		if q.ByAsset && g.Symbol == "" {
+			g.Symbol = r.Symbol
+		}
+		for _, target := range []*Group{g, &a.Total} {
+			target.add(tradeType, amount, notional, priced, expected, known)
+		}
+	}
+
+	for _, g := range groups {
+		g.finish()
+		a.Groups = append(a.Groups, *g)
+	}
+	a.Total.finish()
+
+	sort.Slice(a.Groups, func(i, j int) bool {
+		gi, gj := a.Groups[i], a.Groups[j]
+		if !gi.Start.Equal(gj.Start) {
+			return gi.Start.Before(gj.Start)
+		}
+		if gi.AssetID != gj.AssetID {
+			return gi.AssetID < gj.AssetID
+		}
+		return gi.TradeType < gj.TradeType
+	})
+	sort.SliceStable(a.Anomalies, func(i, j int) bool {
+		return a.Anomalies[i].Record.CreatedAt.Before(a.Anomalies[j].Record.CreatedAt.Time)
+	})
+	return a, nil
+}
+
+type groupKey struct {
+	assetID   string
+	tradeType mudrex.TradeType
+	period    string
+	start     time.Time
+}
+
+func (g *Group) add(tradeType mudrex.TradeType, amount, notional float64, priced bool, expected float64, known bool) {
+	g.Count++
+	g.TotalFees += amount
+	if priced {
+		g.Notional += notional
+		g.pricedFees += amount
+	} else {
+		g.Unpriced++
+	}
+	switch tradeType {
+	case mudrex.TradeTypeMaker:
+		g.MakerCount++
+		g.MakerFees += amount
+		if priced {
+			g.makerNotional += notional
+		}
+	case mudrex.TradeTypeTaker:
+		g.TakerCount++
+		g.TakerFees += amount
+	}
+	if known {
+		g.ExpectedFees += expected
+		g.Excess += amount - expected
+	}
+}
+
+func (g *Group) finish() {
+	if g.Notional > 0 {
+		g.MakerRatio = g.makerNotional / g.Notional
+		g.EffectiveRate = g.pricedFees / g.Notional
+	} else if g.Count > 0 {
+		g.MakerRatio = float64(g.MakerCount) / float64(g.Count)
+	}
+}
+
+// advertisedRate returns the asset's fee for a trade type
+func advertisedRate(asset mudrex.Asset, tradeType mudrex.TradeType) (float64, bool, error) {
+	var value string
+	switch tradeType {
+	case mudrex.TradeTypeMaker:
+		value = asset.MakerFee
+	case mudrex.TradeTypeTaker:
+		value = asset.TakerFee
+	default:
+		return 0, false, nil
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+	rate, err := mudrex.ParseDecimal(strings.ToLower(string(tradeType))+"_fee", value)
+	if err != nil {
+		return 0, false, fmt.Errorf("asset %s: %w", asset.AssetID, err)
+	}
+	return rate, true, nil
+}
+
+// recordNotionals maps the index of each record linked to a filled order to
+// its share of the order's notional, split by fee amount, or evenly when the
+// order's fees sum to zero
+func recordNotionals(records []mudrex.FeeRecord, orders []mudrex.Order) (map[int]float64, error) {
+	orderNotional := make(map[string]float64, len(orders))
+	for _, o := range orders {
+		quantity, err := mudrex.ParseDecimal("filled_quantity", o.FilledQuantity)
+		if err != nil {
+			return nil, fmt.Errorf("order %s: %w", o.OrderID, err)
+		}
+		price, err := mudrex.ParseDecimal("avg_filled_price", o.AvgFilledPrice)
+		if err != nil {
+			return nil, fmt.Errorf("order %s: %w", o.OrderID, err)
+		}
+		if notional := math.Abs(quantity * price); notional > 0 {
+			orderNotional[o.OrderID] = notional
+		}
+	}
+
+	type share struct {
+		fees    float64
+		records []int
+		amounts []float64
+	}
+	shares := make(map[string]*share)
+	for i, r := range records {
+		if _, ok := orderNotional[r.OrderID]; !ok || r.OrderID == "" {
+			continue
+		}
+		amount, err := mudrex.ParseDecimal("fee_amount", r.FeeAmount)
+		if err != nil {
+			return nil, fmt.Errorf("fee for order %s: %w", r.OrderID, err)
+		}
+		sh := shares[r.OrderID]
+		if sh == nil {
+			sh = &share{}
+			shares[r.OrderID] = sh
+		}
+		sh.fees += math.Abs(amount)
+		sh.records = append(sh.records, i)
+		sh.amounts = append(sh.amounts, math.Abs(amount))
+	}
+
+	notionals := make(map[int]float64)
+	for orderID, sh := range shares {
+		for k, i := range sh.records {
+			weight := 1 / float64(len(sh.records))
+			if sh.fees > 0 {
+				weight = sh.amounts[k] / sh.fees
+			}
+			notionals[i] = orderNotional[orderID] * weight
+		}
+	}
+	return notionals, nil
+}
+
+// check flags a record whose charged rate deviates from the advertised rate
+// by more than tolerance
+func check(r mudrex.FeeRecord, tradeType mudrex.TradeType, charged, advertised, tolerance float64) *Anomaly {
+	deviation := math.Abs(charged - advertised)
+	if advertised != 0 {
+		deviation /= math.Abs(advertised)
+	}
+	if deviation <= tolerance {
+		return nil
+	}
+
+	direction := "above"
+	if charged < advertised {
+		direction = "below"
+	}
+	return &Anomaly{
+		Record:         r,
+		Reason:         fmt.Sprintf("%s rate %s advertised %s fee", strings.ToLower(string(tradeType)), direction, strings.ToLower(string(tradeType))),
+		ChargedRate:    charged,
+		AdvertisedRate: advertised,
+	}
+}
+
+// bucketOf returns the start and label of the bucket containing t
+func bucketOf(t time.Time, bucket Bucket, loc *time.Location) (time.Time, string) {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	switch bucket {
+	case BucketDay:
+		return day, day.Format("2006-01-02")
+	case BucketWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		year, week := start.ISOWeek()
+		return start, fmt.Sprintf("%d-W%02d", year, week)
+	default:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		return start, start.Format("2006-01")
+	}
+}
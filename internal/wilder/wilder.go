@@ -0,0 +1,22 @@
+// Package wilder holds J. Welles Wilder's smoothing and true range, shared by
+// the SDK's ATR position sizing and the ta package's RSI and ATR so both
+// compute identical values.
+package wilder
+
+import "math"
+
+// TrueRange is the largest of a bar's range and its distance from the
+// previous close
+func TrueRange(high, low, prevClose float64) float64 {
+	return math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+}
+
+// Smooth folds v, the count-th value of a series, into avg: the simple
+// average of the first period values, then (avg*(period-1) + v) / period
+func Smooth(avg, v float64, count, period int) float64 {
+	n := float64(period)
+	if count <= period {
+		return avg + v/n
+	}
+	return (avg*(n-1) + v) / n
+}
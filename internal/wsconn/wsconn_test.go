@@ -0,0 +1,243 @@
+package wsconn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoServer upgrades every request and echoes messages back until the
+// client closes
+func echoServer(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(op, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func dial(t *testing.T, url string) *Conn {
+	t.Helper()
+	conn, err := Dial(context.Background(), url, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestAcceptKey(t *testing.T) {
+	// Example from RFC 6455 section 1.3
+	if got, want := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("acceptKey = %q, want %q", got, want)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	conn := dial(t, echoServer(t))
+
+	tests := []struct {
+		name   string
+		opcode int
+		size   int
+	}{
+		{"empty", OpText, 0},
+		{"7-bit length", OpText, 125},
+		{"16-bit length", OpBinary, 126},
+		{"largest 16-bit length", OpBinary, 0xFFFF},
+		{"64-bit length", OpBinary, 0x10000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte{'x'}, tt.size)
+			if err := conn.WriteMessage(tt.opcode, data); err != nil {
+				t.Fatalf("WriteMessage: %v", err)
+			}
+			op, got, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage: %v", err)
+			}
+			if op != tt.opcode || !bytes.Equal(got, data) {
+				t.Errorf("got opcode %d with %d bytes, want opcode %d with %d bytes", op, len(got), tt.opcode, len(data))
+			}
+		})
+	}
+}
+
+// rawPair returns a server Conn reading from a pipe and the raw client end
+func rawPair(t *testing.T) (*Conn, net.Conn) {
+	t.Helper()
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+	server.SetDeadline(time.Now().Add(5 * time.Second))
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	return &Conn{conn: server, br: bufio.NewReader(server)}, client
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames []byte
+		opcode int
+		data   string
+	}{
+		{
+			name:   "unmasked",
+			frames: []byte{0x81, 0x05, 'H', 'e', 'l', 'l', 'o'},
+			opcode: OpText,
+			data:   "Hello",
+		},
+		{
+			// Example from RFC 6455 section 5.7
+			name:   "masked",
+			frames: []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58},
+			opcode: OpText,
+			data:   "Hello",
+		},
+		{
+			name:   "fragmented",
+			frames: []byte{0x01, 0x03, 'H', 'e', 'l', 0x80, 0x02, 'l', 'o'},
+			opcode: OpText,
+			data:   "Hello",
+		},
+		{
+			name:   "pong between fragments",
+			frames: []byte{0x02, 0x02, 'a', 'b', 0x8A, 0x00, 0x80, 0x01, 'c'},
+			opcode: OpBinary,
+			data:   "abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, client := rawPair(t)
+			go client.Write(tt.frames)
+
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage: %v", err)
+			}
+			if op != tt.opcode || string(data) != tt.data {
+				t.Errorf("got (%d, %q), want (%d, %q)", op, data, tt.opcode, tt.data)
+			}
+		})
+	}
+}
+
+func TestReadMessageErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames []byte
+	}{
+		{"continuation without start", []byte{0x80, 0x01, 'a'}},
+		{"new message inside fragments", []byte{0x01, 0x01, 'a', 0x81, 0x01, 'b'}},
+		{"oversized frame", []byte{0x82, 0x7F, 0, 0, 0, 0, 0x10, 0, 0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, client := rawPair(t)
+			go client.Write(tt.frames)
+
+			if _, _, err := conn.ReadMessage(); err == nil {
+				t.Error("ReadMessage succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestPingAnsweredWithPong(t *testing.T) {
+	conn, client := rawPair(t)
+	go client.Write([]byte{0x89, 0x02, 'h', 'i', 0x81, 0x01, 'x'})
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		done <- err
+	}()
+
+	pong := make([]byte, 4)
+	if _, err := io.ReadFull(client, pong); err != nil {
+		t.Fatalf("read pong: %v", err)
+	}
+	if want := []byte{0x8A, 0x02, 'h', 'i'}; !bytes.Equal(pong, want) {
+		t.Errorf("pong = %x, want %x", pong, want)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("ReadMessage: %v", err)
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	conn, client := rawPair(t)
+	go client.Write([]byte{0x88, 0x02, 0x03, 0xE8})
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		done <- err
+	}()
+
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(client, reply); err != nil {
+		t.Fatalf("read close reply: %v", err)
+	}
+	if want := []byte{0x88, 0x02, 0x03, 0xE8}; !bytes.Equal(reply, want) {
+		t.Errorf("close reply = %x, want %x", reply, want)
+	}
+	if err := <-done; !errors.Is(err, ErrClosed) {
+		t.Errorf("ReadMessage error = %v, want ErrClosed", err)
+	}
+	if err := conn.WriteMessage(OpText, []byte("late")); !errors.Is(err, ErrClosed) {
+		t.Errorf("WriteMessage after close = %v, want ErrClosed", err)
+	}
+}
+
+func TestClientFramesAreMasked(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+	server.SetDeadline(time.Now().Add(5 * time.Second))
+	conn := &Conn{conn: client, br: bufio.NewReader(client), client: true}
+
+	go conn.WriteMessage(OpText, []byte("Hello"))
+
+	frame := make([]byte, 11)
+	if _, err := io.ReadFull(server, frame); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	if frame[0] != 0x81 || frame[1] != 0x85 {
+		t.Fatalf("header = %x, want 8185", frame[:2])
+	}
+	payload := frame[6:]
+	for i := range payload {
+		payload[i] ^= frame[2+i%4]
+	}
+	if string(payload) != "Hello" {
+		t.Errorf("unmasked payload = %q, want %q", payload, "Hello")
+	}
+}
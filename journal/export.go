@@ -0,0 +1,101 @@
+package journal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// OrderColumns is the CSV header for order exports. The column set and
+// order are part of the export schema and only ever grow at the end.
+var OrderColumns = []string{
+	"order_id", "position_id", "asset_id", "symbol", "side", "trigger_type", "status",
+	"quantity", "filled_quantity", "price", "avg_filled_price", "leverage",
+	"trade_type", "fee_rate", "fee", "created_at",
+}
+
+// PositionColumns is the CSV header for position exports. The column set and
+// order are part of the export schema and only ever grow at the end.
+var PositionColumns = []string{
+	"position_id", "asset_id", "symbol", "side", "status", "quantity", "entry_price",
+	"leverage", "opened_at", "closed_at", "realized_pnl", "fees", "net_pnl", "order_ids",
+}
+
+// WriteOrdersCSV writes order entries as CSV with an OrderColumns header
+func (j *Journal) WriteOrdersCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(OrderColumns); err != nil {
+		return err
+	}
+	for _, o := range j.Orders {
+		record := []string{
+			o.OrderID, o.PositionID, o.AssetID, o.Symbol, o.Side, o.TriggerType, o.Status,
+			o.Quantity, o.FilledQuantity, o.Price, o.AvgFilledPrice, o.Leverage,
+			o.TradeType, o.FeeRate, o.Fee, formatTime(o.CreatedAt),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WritePositionsCSV writes position entries as CSV with a PositionColumns
+// header. Linked order IDs are separated by semicolons.
+func (j *Journal) WritePositionsCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(PositionColumns); err != nil {
+		return err
+	}
+	for _, p := range j.Positions {
+		record := []string{
+			p.PositionID, p.AssetID, p.Symbol, p.Side, p.Status, p.Quantity, p.EntryPrice,
+			p.Leverage, formatTime(p.OpenedAt), formatTimePtr(p.ClosedAt),
+			p.RealizedPnL, p.Fees, p.NetPnL, strings.Join(p.OrderIDs, ";"),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteOrdersJSONL writes one JSON object per order entry
+func (j *Journal) WriteOrdersJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, o := range j.Orders {
+		if err := enc.Encode(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePositionsJSONL writes one JSON object per position entry
+func (j *Journal) WritePositionsJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, p := range j.Positions {
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
@@ -0,0 +1,345 @@
+// Package journal reconciles order, position and fee history into a trade
+// journal with realized P&L net of fees, exportable as CSV or JSON Lines.
+package journal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// linkTolerance allows for clock skew between order and position timestamps
+const linkTolerance = time.Second
+
+// OrderEntry is an order with its fee attached
+type OrderEntry struct {
+	OrderID        string    `json:"order_id"`
+	PositionID     string    `json:"position_id"`
+	AssetID        string    `json:"asset_id"`
+	Symbol         string    `json:"symbol"`
+	Side           string    `json:"side"`
+	TriggerType    string    `json:"trigger_type"`
+	Status         string    `json:"status"`
+	Quantity       string    `json:"quantity"`
+	FilledQuantity string    `json:"filled_quantity"`
+	Price          string    `json:"price"`
+	AvgFilledPrice string    `json:"avg_filled_price"`
+	Leverage       string    `json:"leverage"`
+	TradeType      string    `json:"trade_type"`
+	FeeRate        string    `json:"fee_rate"`
+	Fee            string    `json:"fee"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PositionEntry is a position with realized P&L net of the fees of the
+// orders linked to it. ClosedAt is nil, and null in JSON, while the position
+// is open.
+type PositionEntry struct {
+	PositionID  string     `json:"position_id"`
+	AssetID     string     `json:"asset_id"`
+	Symbol      string     `json:"symbol"`
+	Side        string     `json:"side"`
+	Status      string     `json:"status"`
+	Quantity    string     `json:"quantity"`
+	EntryPrice  string     `json:"entry_price"`
+	Leverage    string     `json:"leverage"`
+	OpenedAt    time.Time  `json:"opened_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	RealizedPnL string     `json:"realized_pnl"`
+	Fees        string     `json:"fees"`
+	NetPnL      string     `json:"net_pnl"`
+	OrderIDs    []string   `json:"order_ids"`
+}
+
+// Journal is the reconciled history
+type Journal struct {
+	Orders    []OrderEntry
+	Positions []PositionEntry
+	// UnmatchedFees are fee records whose order was not found in the history
+	UnmatchedFees []mudrex.FeeRecord
+	// UnlinkedFees sums the fees of orders not linked to any position
+	UnlinkedFees float64
+	// TotalRealizedPnL sums the position entries. TotalFees adds the
+	// unlinked and unmatched fees to the positions' fees, so it covers every
+	// fee paid, and TotalNetPnL is TotalRealizedPnL less TotalFees.
+	TotalRealizedPnL float64
+	TotalFees        float64
+	TotalNetPnL      float64
+}
+
+// Builder pages through history endpoints and assembles a Journal
+type Builder struct {
+	client *mudrex.Client
+
+	// Assets whose order history is fetched. When empty, the assets seen in
+	// position and fee history are used.
+	Assets []string
+	// PerPage is the page size for history requests (default 100)
+	PerPage int
+	// MaxPages bounds paging per endpoint (default 1000); Fetch fails with
+	// mudrex.ErrPageLimit rather than return a truncated history
+	MaxPages int
+	// From and To, when set, restrict the journal to [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// NewBuilder creates a builder fetching history through client
+func NewBuilder(client *mudrex.Client) *Builder {
+	return &Builder{client: client, PerPage: mudrex.DefaultPerPage, MaxPages: mudrex.DefaultMaxPages}
+}
+
+// History is the raw order, position and fee history behind a journal
+type History struct {
+	Orders    []mudrex.Order
+	Positions []mudrex.Position
+	Fees      []mudrex.FeeRecord
+}
+
+// Fetch pages through position, fee and order history
+func (b *Builder) Fetch() (*History, error) {
+	positions, err := mudrex.PageAll(b.PerPage, b.MaxPages, b.client.Positions.GetHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch position history: %w", err)
+	}
+
+	fees, err := mudrex.PageAll(b.PerPage, b.MaxPages, b.client.Fees.GetHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fee history: %w", err)
+	}
+
+	assets := b.Assets
+	if len(assets) == 0 {
+		seen := make(map[string]bool)
+		for _, p := range positions {
+			if p.AssetID != "" && !seen[p.AssetID] {
+				seen[p.AssetID] = true
+				assets = append(assets, p.AssetID)
+			}
+		}
+		for _, f := range fees {
+			if f.AssetID != "" && !seen[f.AssetID] {
+				seen[f.AssetID] = true
+				assets = append(assets, f.AssetID)
+			}
+		}
+		sort.Strings(assets)
+	}
+
+	var orders []mudrex.Order
+	for _, assetID := range assets {
+		assetOrders, err := mudrex.PageAll(b.PerPage, b.MaxPages, func(page, perPage int) ([]mudrex.Order, error) {
+			return b.client.Orders.GetHistory(assetID, page, perPage)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch order history for %s: %w", assetID, err)
+		}
+		orders = append(orders, assetOrders...)
+	}
+
+	return &History{Orders: orders, Positions: positions, Fees: fees}, nil
+}
+
+// Build fetches position, fee and order history and reconciles them
+func (b *Builder) Build() (*Journal, error) {
+	h, err := b.Fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := Assemble(h.Orders, h.Positions, h.Fees)
+	if err != nil {
+		return nil, err
+	}
+	j.filter(b.From, b.To)
+	return j, nil
+}
+
+// Assemble reconciles already-fetched history. Fees are linked to orders by
+// FeeRecord.OrderID; orders are linked to the position on the same asset
+// whose lifetime contains the order's creation time.
+func Assemble(orders []mudrex.Order, positions []mudrex.Position, fees []mudrex.FeeRecord) (*Journal, error) {
+	feesByOrder := make(map[string][]mudrex.FeeRecord)
+	for _, f := range fees {
+		feesByOrder[f.OrderID] = append(feesByOrder[f.OrderID], f)
+	}
+
+	sortedPositions := append([]mudrex.Position(nil), positions...)
+	sort.SliceStable(sortedPositions, func(i, k int) bool {
+		return sortedPositions[i].CreatedAt.Before(sortedPositions[k].CreatedAt.Time)
+	})
+
+	j := &Journal{}
+	positionFees := make(map[string]float64)
+	positionOrders := make(map[string][]string)
+	matched := make(map[string]bool)
+
+	for _, o := range orders {
+		entry := OrderEntry{
+			OrderID:        o.OrderID,
+			AssetID:        o.AssetID,
+			Symbol:         o.Symbol,
+			Side:           string(o.OrderType),
+			TriggerType:    string(o.TriggerType),
+			Status:         string(o.Status),
+			Quantity:       o.Quantity,
+			FilledQuantity: o.FilledQuantity,
+			Price:          o.Price,
+			AvgFilledPrice: o.AvgFilledPrice,
+			Leverage:       o.Leverage,
+			CreatedAt:      o.CreatedAt.Time,
+		}
+
+		var fee float64
+		for _, f := range feesByOrder[o.OrderID] {
+			amount, err := mudrex.ParseDecimal("fee_amount", f.FeeAmount)
+			if err != nil {
+				return nil, fmt.Errorf("fee for order %s: %w", o.OrderID, err)
+			}
+			fee += amount
+			entry.TradeType = string(f.TradeType)
+			entry.FeeRate = f.FeeRate
+		}
+		if _, ok := feesByOrder[o.OrderID]; ok {
+			matched[o.OrderID] = true
+			entry.Fee = format(fee)
+		}
+
+		if p := positionFor(sortedPositions, o); p != nil {
+			entry.PositionID = p.PositionID
+			positionFees[p.PositionID] += fee
+			positionOrders[p.PositionID] = append(positionOrders[p.PositionID], o.OrderID)
+		}
+
+		j.Orders = append(j.Orders, entry)
+	}
+
+	for _, f := range fees {
+		if matched[f.OrderID] {
+			continue
+		}
+		if _, err := mudrex.ParseDecimal("fee_amount", f.FeeAmount); err != nil {
+			return nil, fmt.Errorf("fee for order %s: %w", f.OrderID, err)
+		}
+		j.UnmatchedFees = append(j.UnmatchedFees, f)
+	}
+
+	for _, p := range sortedPositions {
+		realized, err := mudrex.ParseDecimal("realized_pnl", p.RealizedPnL)
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", p.PositionID, err)
+		}
+		fee := positionFees[p.PositionID]
+
+		entry := PositionEntry{
+			PositionID:  p.PositionID,
+			AssetID:     p.AssetID,
+			Symbol:      p.Symbol,
+			Side:        string(p.Side),
+			Status:      string(p.Status),
+			Quantity:    p.Quantity,
+			EntryPrice:  p.EntryPrice,
+			Leverage:    p.Leverage,
+			OpenedAt:    p.CreatedAt.Time,
+			RealizedPnL: format(realized),
+			Fees:        format(fee),
+			NetPnL:      format(realized - fee),
+			OrderIDs:    positionOrders[p.PositionID],
+		}
+		if p.Status != mudrex.PositionStatusOpen && !p.UpdatedAt.IsZero() {
+			closed := p.UpdatedAt.Time
+			entry.ClosedAt = &closed
+		}
+		if entry.OrderIDs == nil {
+			entry.OrderIDs = []string{}
+		}
+		j.Positions = append(j.Positions, entry)
+	}
+
+	sort.SliceStable(j.Orders, func(i, k int) bool {
+		return j.Orders[i].CreatedAt.Before(j.Orders[k].CreatedAt)
+	})
+	j.total()
+	return j, nil
+}
+
+// positionFor finds the position an order belongs to
+func positionFor(positions []mudrex.Position, o mudrex.Order) *mudrex.Position {
+	for i := range positions {
+		p := &positions[i]
+		sameAsset := (o.AssetID != "" && p.AssetID == o.AssetID) || (o.Symbol != "" && p.Symbol == o.Symbol)
+		if !sameAsset || o.CreatedAt.Before(p.CreatedAt.Add(-linkTolerance)) {
+			continue
+		}
+		if p.Status == mudrex.PositionStatusOpen || !o.CreatedAt.After(p.UpdatedAt.Add(linkTolerance)) {
+			return p
+		}
+	}
+	return nil
+}
+
+// filter keeps orders created and positions closed within [from, to)
+func (j *Journal) filter(from, to time.Time) {
+	in := func(t time.Time) bool {
+		return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+	}
+
+	orders := j.Orders[:0]
+	for _, o := range j.Orders {
+		if in(o.CreatedAt) {
+			orders = append(orders, o)
+		}
+	}
+	j.Orders = orders
+
+	positions := j.Positions[:0]
+	for _, p := range j.Positions {
+		at := p.OpenedAt
+		if p.ClosedAt != nil {
+			at = *p.ClosedAt
+		}
+		if in(at) {
+			positions = append(positions, p)
+		}
+	}
+	j.Positions = positions
+
+	fees := j.UnmatchedFees[:0]
+	for _, f := range j.UnmatchedFees {
+		if in(f.CreatedAt.Time) {
+			fees = append(fees, f)
+		}
+	}
+	j.UnmatchedFees = fees
+
+	j.total()
+}
+
+func (j *Journal) total() {
+	j.UnlinkedFees, j.TotalRealizedPnL, j.TotalFees = 0, 0, 0
+	for _, p := range j.Positions {
+		realized, _ := mudrex.ParseDecimal("realized_pnl", p.RealizedPnL)
+		fees, _ := mudrex.ParseDecimal("fees", p.Fees)
+		j.TotalRealizedPnL += realized
+		j.TotalFees += fees
+	}
+	for _, o := range j.Orders {
+		if o.PositionID == "" {
+			fee, _ := mudrex.ParseDecimal("fee", o.Fee)
+			j.UnlinkedFees += fee
+		}
+	}
+	j.TotalFees += j.UnlinkedFees
+	for _, f := range j.UnmatchedFees {
+		fee, _ := mudrex.ParseDecimal("fee_amount", f.FeeAmount)
+		j.TotalFees += fee
+	}
+	j.TotalNetPnL = j.TotalRealizedPnL - j.TotalFees
+}
+
+func format(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,334 @@
+package mudrex
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader carries client-generated idempotency keys
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// NewIdempotencyKey returns a random key for TransferWithKey or CreateWithKey
+func NewIdempotencyKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generate idempotency key: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+func idempotencyHeader(key string) http.Header {
+	if key == "" {
+		return nil
+	}
+	return http.Header{IdempotencyKeyHeader: []string{key}}
+}
+
+// TransferState is the known outcome of a ledgered transfer
+type TransferState string
+
+const (
+	// TransferPending is recorded before the request is sent
+	TransferPending TransferState = "PENDING"
+	// TransferCompleted means the funds moved
+	TransferCompleted TransferState = "COMPLETED"
+	// TransferFailed means the funds did not move
+	TransferFailed TransferState = "FAILED"
+	// TransferUnknown means the request may or may not have been applied,
+	// e.g. after a timeout; Reconcile resolves it
+	TransferUnknown TransferState = "UNKNOWN"
+)
+
+// ErrUnresolvedTransfers is returned by LedgeredTransfer while earlier
+// transfers have unknown outcomes
+var ErrUnresolvedTransfers = errors.New("ledger has transfers with unknown outcome; reconcile first")
+
+// LedgerEntry records the intent and outcome of one transfer
+type LedgerEntry struct {
+	Key       string        `json:"key"`
+	From      WalletType    `json:"from"`
+	To        WalletType    `json:"to"`
+	Amount    string        `json:"amount"`
+	State     TransferState `json:"state"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	// FuturesBefore is the futures balance read just before sending
+	FuturesBefore string `json:"futures_before"`
+	// FuturesAfter is the futures balance observed when reconciling
+	FuturesAfter  string `json:"futures_after,omitempty"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+	// Resolution explains how Reconcile decided the outcome
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// Resolved reports whether the entry's outcome is known
+func (e LedgerEntry) Resolved() bool {
+	return e.State == TransferCompleted || e.State == TransferFailed
+}
+
+// Ledger is an append-only JSON Lines log of transfers. Each state change
+// appends the full entry; loading keeps the latest line per key.
+type Ledger struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*LedgerEntry
+	order   []string
+}
+
+// OpenLedger loads the ledger at path, creating it if it does not exist
+func OpenLedger(path string) (*Ledger, error) {
+	l := &Ledger{path: path, entries: make(map[string]*LedgerEntry)}
+
+	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry LedgerEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("ledger line %d: %w", line, err)
+		}
+		l.apply(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ledger: %w", err)
+	}
+	return l, nil
+}
+
+// Entries returns every transfer in the order first recorded
+func (l *Ledger) Entries() []LedgerEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]LedgerEntry, 0, len(l.order))
+	for _, key := range l.order {
+		entries = append(entries, *l.entries[key])
+	}
+	return entries
+}
+
+// Get returns the entry for key
+func (l *Ledger) Get(key string) (LedgerEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return LedgerEntry{}, false
+	}
+	return *entry, true
+}
+
+// Unresolved returns the transfers that are pending or unknown
+func (l *Ledger) Unresolved() []LedgerEntry {
+	var unresolved []LedgerEntry
+	for _, entry := range l.Entries() {
+		if !entry.Resolved() {
+			unresolved = append(unresolved, entry)
+		}
+	}
+	return unresolved
+}
+
+// Resolve records a manually determined outcome for key, e.g. after checking
+// the transfer history when Reconcile could not decide
+func (l *Ledger) Resolve(key string, state TransferState, resolution string) error {
+	if state != TransferCompleted && state != TransferFailed {
+		return fmt.Errorf("cannot resolve transfer to state %s", state)
+	}
+	entry, ok := l.Get(key)
+	if !ok {
+		return fmt.Errorf("no transfer with key %s", key)
+	}
+	entry.State = state
+	entry.Resolution = resolution
+	return l.record(entry)
+}
+
+// record appends entry to the file and syncs it before updating memory
+func (l *Ledger) record(entry LedgerEntry) error {
+	entry.UpdatedAt = time.Now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync ledger: %w", err)
+	}
+
+	l.apply(entry)
+	return nil
+}
+
+func (l *Ledger) apply(entry LedgerEntry) {
+	if _, ok := l.entries[entry.Key]; !ok {
+		l.order = append(l.order, entry.Key)
+	}
+	l.entries[entry.Key] = &entry
+}
+
+// LedgeredTransfer records the transfer's intent and the futures balance in
+// ledger, sends it with a fresh idempotency key and records the outcome.
+// Errors that leave the outcome unknown (timeouts, server errors, unreadable
+// responses) are recorded as TransferUnknown for Reconcile rather than
+// retried. No new transfer starts while any are unresolved.
+func (w *WalletAPI) LedgeredTransfer(ledger *Ledger, fromWallet, toWallet WalletType, amount string) (*LedgerEntry, error) {
+	if len(ledger.Unresolved()) > 0 {
+		return nil, ErrUnresolvedTransfers
+	}
+
+	key, err := NewIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+	before, err := w.GetFuturesBalance()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	entry := LedgerEntry{
+		Key:           key,
+		From:          fromWallet,
+		To:            toWallet,
+		Amount:        amount,
+		State:         TransferPending,
+		CreatedAt:     now,
+		FuturesBefore: before.Balance,
+	}
+	if err := ledger.record(entry); err != nil {
+		return nil, err
+	}
+
+	result, transferErr := w.TransferWithKey(entry.Key, fromWallet, toWallet, amount)
+	switch {
+	case transferErr == nil && result.Success:
+		entry.State = TransferCompleted
+		entry.TransactionID = result.TransactionID
+	case transferErr == nil:
+		entry.State = TransferFailed
+		entry.TransactionID = result.TransactionID
+		entry.Error = "transfer was not successful"
+	case IsRejected(transferErr):
+		entry.State = TransferFailed
+		entry.Error = transferErr.Error()
+	default:
+		entry.State = TransferUnknown
+		entry.Error = transferErr.Error()
+	}
+
+	if err := ledger.record(entry); err != nil {
+		return &entry, errors.Join(transferErr, err)
+	}
+	return &entry, transferErr
+}
+
+// Reconcile resolves pending and unknown transfers by comparing the current
+// futures balance with the balance recorded before they were sent. If the
+// balance moved by the transfers' combined amount (within tolerance) they
+// are marked completed; if it did not move they are marked failed. Any other
+// movement, e.g. from trading in the meantime, leaves them unknown with an
+// explanation. It returns the entries it examined.
+func (w *WalletAPI) Reconcile(ledger *Ledger, tolerance float64) ([]LedgerEntry, error) {
+	unresolved := ledger.Unresolved()
+	if len(unresolved) == 0 {
+		return nil, nil
+	}
+
+	balance, err := w.GetFuturesBalance()
+	if err != nil {
+		return nil, err
+	}
+	after, err := strconv.ParseFloat(balance.Balance, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid futures balance %q", balance.Balance)
+	}
+	before, err := strconv.ParseFloat(unresolved[0].FuturesBefore, 64)
+	if err != nil {
+		return nil, fmt.Errorf("transfer %s: invalid recorded balance %q", unresolved[0].Key, unresolved[0].FuturesBefore)
+	}
+
+	var expected float64
+	for _, entry := range unresolved {
+		amount, err := strconv.ParseFloat(entry.Amount, 64)
+		if err != nil {
+			return nil, fmt.Errorf("transfer %s: invalid amount %q", entry.Key, entry.Amount)
+		}
+		if entry.To == WalletTypeFutures {
+			expected += amount
+		} else {
+			expected -= amount
+		}
+	}
+
+	moved := after - before
+	state, resolution := TransferUnknown, ""
+	switch {
+	case math.Abs(moved-expected) <= tolerance:
+		state = TransferCompleted
+		resolution = fmt.Sprintf("futures balance moved by %v as expected", moved)
+	case math.Abs(moved) <= tolerance:
+		state = TransferFailed
+		resolution = "futures balance unchanged"
+	default:
+		resolution = fmt.Sprintf("futures balance moved by %v, expected %v or 0", moved, expected)
+	}
+
+	for i := range unresolved {
+		unresolved[i].State = state
+		unresolved[i].FuturesAfter = balance.Balance
+		unresolved[i].Resolution = resolution
+		if err := ledger.record(unresolved[i]); err != nil {
+			return nil, err
+		}
+	}
+	return unresolved, nil
+}
+
+// IsRejected reports whether err is a definitive API rejection, after which
+// the request is known not to have been applied. Any other error from a
+// write, such as a timeout, leaves its outcome unknown.
+func IsRejected(err error) bool {
+	var (
+		validation   *ValidationError
+		notFound     *NotFoundError
+		conflict     *ConflictError
+		auth         *AuthenticationError
+		rateLimit    *RateLimitError
+		insufficient *InsufficientBalanceError
+	)
+	return errors.As(err, &validation) || errors.As(err, &notFound) || errors.As(err, &conflict) ||
+		errors.As(err, &auth) || errors.As(err, &rateLimit) || errors.As(err, &insufficient)
+}
@@ -1,66 +1,216 @@
 package mudrex
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"sync"
 )
 
 // LeverageAPI handles leverage-related endpoints
 type LeverageAPI struct {
 	client *Client
+
+	mu    sync.Mutex
+	known map[string]Leverage
 }
 
 // Get retrieves the current leverage settings for an asset
 func (l *LeverageAPI) Get(assetID string) (*Leverage, error) {
-	path := fmt.Sprintf("/futures/%s/leverage", assetID)
+	leverage, err := doPtr[Leverage](l.client, request{
+		method: "GET",
+		path:   fmt.Sprintf("/futures/%s/leverage", assetID),
+		op:     "get leverage",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if leverage.AssetID == "" {
+		leverage.AssetID = assetID
+	}
 	
-	resp, err := l.client.Get(path)
+	l.remember(assetID, *leverage)
+	return leverage, nil
+}
+
+// Set sets the leverage and margin type for an asset
+func (l *LeverageAPI) Set(assetID string, leverage string, marginType MarginType) (*Leverage, error) {
+	result, err := doPtr[Leverage](l.client, request{
+		method: "PATCH",
+		path:   fmt.Sprintf("/futures/%s/leverage", assetID),
+		body: map[string]interface{}{
+			"leverage":    leverage,
+			"margin_type": marginType,
+		},
+		op: "set leverage",
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get leverage: %w", err)
+		return nil, err
+	}
+	if result.AssetID == "" {
+		result.AssetID = assetID
+	}
+	if result.Leverage == "" {
+		result.Leverage = leverage
+	}
+	if result.MarginType == "" {
+		result.MarginType = marginType
 	}
 	
-	var apiResp APIResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	l.remember(assetID, *result)
+	return result, nil
+}
+
+// Ensure makes the asset's leverage and margin type match, reading the
+// current setting (from the cache, or with Get) and only calling Set when it
+// differs. An empty marginType keeps the current one. It reports whether a
+// write was made.
+func (l *LeverageAPI) Ensure(assetID string, leverage string, marginType MarginType) (*Leverage, bool, error) {
+	current, ok := l.cached(assetID)
+	if !ok {
+		fetched, err := l.Get(assetID)
+		if err != nil {
+			return nil, false, err
+		}
+		current = *fetched
 	}
 	
-	var leverage Leverage
-	if err := json.Unmarshal(apiResp.Data, &leverage); err != nil {
-		return nil, fmt.Errorf("failed to parse leverage: %w", err)
+	if sameLeverage(current.Leverage, leverage) && (marginType == "" || marginType == current.MarginType) {
+		return &current, false, nil
+	}
+	if marginType == "" {
+		marginType = current.MarginType
+	}
+	if marginType == "" {
+		marginType = MarginTypeIsolated
 	}
 	
-	return &leverage, nil
+	result, err := l.Set(assetID, leverage, marginType)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, true, nil
 }
 
-// Set sets the leverage and margin type for an asset
-func (l *LeverageAPI) Set(assetID string, leverage string, marginType MarginType) (*Leverage, error) {
-	path := fmt.Sprintf("/futures/%s/leverage", assetID)
+// InvalidateCache forgets the cached settings of the given assets, or of
+// every asset when none are given, e.g. after changing leverage elsewhere
+func (l *LeverageAPI) InvalidateCache(assetIDs ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	
-	body := map[string]interface{}{
-		"leverage":    leverage,
-		"margin_type": marginType,
+	if len(assetIDs) == 0 {
+		l.known = nil
+		return
+	}
+	for _, assetID := range assetIDs {
+		delete(l.known, assetID)
 	}
+}
+
+// LeverageSpec is the desired setting for one asset
+type LeverageSpec struct {
+	Leverage   string
+	MarginType MarginType
+}
+
+// LeverageResult is the outcome of applying a spec to one asset
+type LeverageResult struct {
+	AssetID  string
+	Leverage *Leverage
+	Changed  bool
+	Err      error
+}
+
+// LeverageResults holds per-asset outcomes sorted by asset ID
+type LeverageResults []LeverageResult
+
+// Err joins every per-asset failure, or returns nil if all succeeded
+func (r LeverageResults) Err() error {
+	var errs []error
+	for _, result := range r {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("asset %s: %w", result.AssetID, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ApplyAll ensures every asset in specs has the desired setting. Each
+// leverage is first validated against the asset's MinLeverage and
+// MaxLeverage; invalid specs fail with a ValidationError without a write.
+func (l *LeverageAPI) ApplyAll(specs map[string]LeverageSpec) LeverageResults {
+	assetIDs := make([]string, 0, len(specs))
+	for assetID := range specs {
+		assetIDs = append(assetIDs, assetID)
+	}
+	sort.Strings(assetIDs)
 	
-	jsonBody, err := json.Marshal(body)
+	results := make(LeverageResults, 0, len(assetIDs))
+	for _, assetID := range assetIDs {
+		spec := specs[assetID]
+		result := LeverageResult{AssetID: assetID}
+		
+		asset, err := l.client.Assets.GetAsset(assetID)
+		if err == nil {
+			err = validateLeverage(asset, spec.Leverage)
+		}
+		if err == nil {
+			result.Leverage, result.Changed, err = l.Ensure(assetID, spec.Leverage, spec.MarginType)
+		}
+		result.Err = err
+		results = append(results, result)
+	}
+	return results
+}
+
+// validateLeverage checks leverage against the asset's limits
+func validateLeverage(asset *Asset, leverage string) error {
+	value, err := strconv.ParseFloat(leverage, 64)
+	if err != nil || value <= 0 {
+		return validationError(fmt.Sprintf("invalid leverage %q", leverage))
+	}
+	min, err := ParseDecimal("min_leverage", asset.MinLeverage)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return err
 	}
-	
-	resp, err := l.client.Patch(path, bytes.NewReader(jsonBody))
+	max, err := ParseDecimal("max_leverage", asset.MaxLeverage)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set leverage: %w", err)
+		return err
 	}
-	
-	var apiResp APIResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if min > 0 && value < min {
+		return validationError(fmt.Sprintf("leverage %s is below the minimum %s for %s", leverage, asset.MinLeverage, asset.AssetID))
 	}
+	if max > 0 && value > max {
+		return validationError(fmt.Sprintf("leverage %s is above the maximum %s for %s", leverage, asset.MaxLeverage, asset.AssetID))
+	}
+	return nil
+}
+
+func (l *LeverageAPI) remember(assetID string, leverage Leverage) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	
-	var result Leverage
-	if err := json.Unmarshal(apiResp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse leverage: %w", err)
+	if l.known == nil {
+		l.known = make(map[string]Leverage)
 	}
+	l.known[assetID] = leverage
+}
+
+func (l *LeverageAPI) cached(assetID string) (Leverage, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	
-	return &result, nil
+	leverage, ok := l.known[assetID]
+	return leverage, ok
+}
+
+// sameLeverage compares leverage values numerically, so "5" equals "5.0"
+func sameLeverage(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return errA == nil && errB == nil && x == y
 }
@@ -0,0 +1,349 @@
+package mudrex
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// MarketAPI handles public market data endpoints
+type MarketAPI struct {
+	client *Client
+}
+
+// Interval is a candle interval
+type Interval string
+
+const (
+	Interval1m  Interval = "1m"
+	Interval5m  Interval = "5m"
+	Interval15m Interval = "15m"
+	Interval30m Interval = "30m"
+	Interval1h  Interval = "1h"
+	Interval4h  Interval = "4h"
+	Interval1d  Interval = "1d"
+	Interval1w  Interval = "1w"
+)
+
+var intervalDurations = map[Interval]time.Duration{
+	Interval1m:  time.Minute,
+	Interval5m:  5 * time.Minute,
+	Interval15m: 15 * time.Minute,
+	Interval30m: 30 * time.Minute,
+	Interval1h:  time.Hour,
+	Interval4h:  4 * time.Hour,
+	Interval1d:  24 * time.Hour,
+	Interval1w:  7 * 24 * time.Hour,
+}
+
+func (i Interval) String() string { return string(i) }
+
+// Valid reports whether i is a known interval
+func (i Interval) Valid() bool {
+	_, ok := intervalDurations[i]
+	return ok
+}
+
+// Duration returns the length of one candle, or zero for unknown intervals
+func (i Interval) Duration() time.Duration {
+	return intervalDurations[i]
+}
+
+// Ticker is the latest price summary of an asset
+type Ticker struct {
+	RawFields `json:"-"`
+
+	AssetID         string    `json:"asset_id"`
+	Symbol          string    `json:"symbol"`
+	LastPrice       string    `json:"last_price"`
+	MarkPrice       string    `json:"mark_price"`
+	IndexPrice      string    `json:"index_price"`
+	BidPrice        string    `json:"bid_price"`
+	AskPrice        string    `json:"ask_price"`
+	High24h         string    `json:"high_24h"`
+	Low24h          string    `json:"low_24h"`
+	Volume24h       string    `json:"volume_24h"`
+	PriceChange24h  string    `json:"price_change_24h"`
+	FundingRate     string    `json:"funding_rate"`
+	NextFundingTime Timestamp `json:"next_funding_time"`
+	Timestamp       Timestamp `json:"timestamp"`
+}
+
+// OrderBookLevel is one price level of an order book
+type OrderBookLevel struct {
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+}
+
+// UnmarshalJSON accepts both {"price": ..., "quantity": ...} objects and
+// [price, quantity] pairs
+func (l *OrderBookLevel) UnmarshalJSON(data []byte) error {
+	var pair []json.Number
+	if err := json.Unmarshal(data, &pair); err == nil {
+		if len(pair) < 2 {
+			return fmt.Errorf("order book level needs price and quantity, got %s", data)
+		}
+		l.Price, l.Quantity = pair[0].String(), pair[1].String()
+		return nil
+	}
+
+	type level OrderBookLevel
+	var obj struct {
+		level
+		Size string `json:"size"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*l = OrderBookLevel(obj.level)
+	if l.Quantity == "" {
+		l.Quantity = obj.Size
+	}
+	return nil
+}
+
+// OrderBook is a depth snapshot; bids are sorted best (highest) first and
+// asks best (lowest) first
+type OrderBook struct {
+	RawFields `json:"-"`
+
+	AssetID   string           `json:"asset_id"`
+	Symbol    string           `json:"symbol"`
+	Bids      []OrderBookLevel `json:"bids"`
+	Asks      []OrderBookLevel `json:"asks"`
+	Timestamp Timestamp        `json:"timestamp"`
+}
+
+// sort orders both sides best first, whatever order the API sent them in
+func (b *OrderBook) sort() error {
+	if err := sortLevels(b.Bids, "bid", func(x, y float64) bool { return x > y }); err != nil {
+		return err
+	}
+	return sortLevels(b.Asks, "ask", func(x, y float64) bool { return x < y })
+}
+
+func sortLevels(levels []OrderBookLevel, side string, better func(x, y float64) bool) error {
+	prices := make(map[string]float64, len(levels))
+	for _, l := range levels {
+		price, err := ParseDecimal(side+" price", l.Price)
+		if err != nil {
+			return err
+		}
+		prices[l.Price] = price
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		return better(prices[levels[i].Price], prices[levels[j].Price])
+	})
+	return nil
+}
+
+// Kline is an OHLCV candle as returned by the API
+type Kline struct {
+	RawFields `json:"-"`
+
+	OpenTime  Timestamp `json:"open_time"`
+	CloseTime Timestamp `json:"close_time"`
+	Open      string    `json:"open"`
+	High      string    `json:"high"`
+	Low       string    `json:"low"`
+	Close     string    `json:"close"`
+	Volume    string    `json:"volume"`
+}
+
+// Candle parses the kline's prices into a Candle for backtests and indicators
+func (k *Kline) Candle() (Candle, error) {
+	c := Candle{Time: k.OpenTime.Time}
+	fields := []struct {
+		name  string
+		value string
+		dst   *float64
+	}{
+		{"open", k.Open, &c.Open},
+		{"high", k.High, &c.High},
+		{"low", k.Low, &c.Low},
+		{"close", k.Close, &c.Close},
+		{"volume", k.Volume, &c.Volume},
+	}
+	for _, f := range fields {
+		v, err := ParseDecimal(f.name, f.value)
+		if err != nil {
+			return Candle{}, err
+		}
+		*f.dst = v
+	}
+	return c, nil
+}
+
+// Candles converts klines with Kline.Candle
+func Candles(klines []Kline) ([]Candle, error) {
+	candles := make([]Candle, len(klines))
+	for i := range klines {
+		c, err := klines[i].Candle()
+		if err != nil {
+			return nil, fmt.Errorf("kline %d: %w", i, err)
+		}
+		candles[i] = c
+	}
+	return candles, nil
+}
+
+// KlineQuery selects candles; zero fields use the API defaults
+type KlineQuery struct {
+	Interval Interval
+	Start    time.Time
+	End      time.Time
+	Limit    int
+}
+
+// Trade is a public trade
+type Trade struct {
+	RawFields `json:"-"`
+
+	TradeID   string    `json:"trade_id"`
+	Price     string    `json:"price"`
+	Quantity  string    `json:"quantity"`
+	Side      OrderType `json:"side"`
+	Timestamp Timestamp `json:"timestamp"`
+}
+
+// FundingRate is one funding settlement
+type FundingRate struct {
+	RawFields `json:"-"`
+
+	AssetID     string    `json:"asset_id"`
+	Symbol      string    `json:"symbol"`
+	FundingRate string    `json:"funding_rate"`
+	MarkPrice   string    `json:"mark_price"`
+	FundingTime Timestamp `json:"funding_time"`
+}
+
+// FundingRateQuery selects funding history; zero fields use the API defaults
+type FundingRateQuery struct {
+	Start time.Time
+	End   time.Time
+	Limit int
+}
+
+// GetTicker retrieves the latest ticker of an asset
+func (m *MarketAPI) GetTicker(assetID string) (*Ticker, error) {
+	return doPtr[Ticker](m.client, request{
+		method: "GET",
+		path:   fmt.Sprintf("/futures/%s/ticker", assetID),
+		op:     "get ticker",
+	})
+}
+
+// ListTickers retrieves the tickers of all assets
+func (m *MarketAPI) ListTickers() ([]Ticker, error) {
+	return do[[]Ticker](m.client, request{
+		method: "GET",
+		path:   "/futures/tickers",
+		op:     "list tickers",
+	})
+}
+
+// GetMarkPrice retrieves the mark price of an asset
+func (m *MarketAPI) GetMarkPrice(assetID string) (float64, error) {
+	ticker, err := m.GetTicker(assetID)
+	if err != nil {
+		return 0, err
+	}
+	return ParseDecimal("mark_price", ticker.MarkPrice)
+}
+
+// GetOrderBook retrieves up to depth levels per side; depth 0 uses the API
+// default
+func (m *MarketAPI) GetOrderBook(assetID string, depth int) (*OrderBook, error) {
+	params := url.Values{}
+	if depth > 0 {
+		params.Set("depth", strconv.Itoa(depth))
+	}
+
+	book, err := doPtr[OrderBook](m.client, request{
+		method: "GET",
+		path:   fmt.Sprintf("/futures/%s/orderbook", assetID),
+		query:  params,
+		op:     "get order book",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := book.sort(); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// GetKlines retrieves OHLCV candles, oldest first
+func (m *MarketAPI) GetKlines(assetID string, q KlineQuery) ([]Kline, error) {
+	if q.Interval != "" && !q.Interval.Valid() {
+		return nil, validationError(fmt.Sprintf("unknown interval %q", q.Interval))
+	}
+	params := timeRangeQuery(q.Start, q.End, q.Limit)
+	if q.Interval != "" {
+		params.Set("interval", string(q.Interval))
+	}
+
+	klines, err := do[[]Kline](m.client, request{
+		method: "GET",
+		path:   fmt.Sprintf("/futures/%s/klines", assetID),
+		query:  params,
+		op:     "get klines",
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(klines, func(i, j int) bool {
+		return klines[i].OpenTime.Before(klines[j].OpenTime.Time)
+	})
+	return klines, nil
+}
+
+// GetCandles is GetKlines with prices parsed into Candles
+func (m *MarketAPI) GetCandles(assetID string, q KlineQuery) ([]Candle, error) {
+	klines, err := m.GetKlines(assetID, q)
+	if err != nil {
+		return nil, err
+	}
+	return Candles(klines)
+}
+
+// GetTrades retrieves up to limit recent trades; limit 0 uses the API
+// default
+func (m *MarketAPI) GetTrades(assetID string, limit int) ([]Trade, error) {
+	return do[[]Trade](m.client, request{
+		method: "GET",
+		path:   fmt.Sprintf("/futures/%s/trades", assetID),
+		query:  timeRangeQuery(time.Time{}, time.Time{}, limit),
+		op:     "get trades",
+	})
+}
+
+// GetFundingRates retrieves funding rate history
+func (m *MarketAPI) GetFundingRates(assetID string, q FundingRateQuery) ([]FundingRate, error) {
+	return do[[]FundingRate](m.client, request{
+		method: "GET",
+		path:   fmt.Sprintf("/futures/%s/funding-rates", assetID),
+		query:  timeRangeQuery(q.Start, q.End, q.Limit),
+		op:     "get funding rates",
+	})
+}
+
+// timeRangeQuery builds start_time and end_time, in epoch milliseconds, and
+// limit parameters
+func timeRangeQuery(start, end time.Time, limit int) url.Values {
+	params := url.Values{}
+	if !start.IsZero() {
+		params.Set("start_time", strconv.FormatInt(start.UnixMilli(), 10))
+	}
+	if !end.IsZero() {
+		params.Set("end_time", strconv.FormatInt(end.UnixMilli(), 10))
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	return params
+}
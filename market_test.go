@@ -0,0 +1,40 @@
+package mudrex_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+	"github.com/DecentralizedJM/mudrex-go-sdk/mudrextest"
+)
+
+func TestGetOrderBookSortsBestFirst(t *testing.T) {
+	server := mudrextest.NewMarketServer()
+	defer server.Close()
+	server.SetOrderBook(mudrex.OrderBook{
+		AssetID: "BTCUSDT",
+		Bids:    []mudrex.OrderBookLevel{{Price: "99.5", Quantity: "1"}, {Price: "100", Quantity: "2"}, {Price: "98", Quantity: "3"}},
+		Asks:    []mudrex.OrderBookLevel{{Price: "102", Quantity: "1"}, {Price: "100.5", Quantity: "2"}, {Price: "101", Quantity: "3"}},
+	})
+
+	client := mudrex.NewClientWithConfig("secret", server.URL, 5*time.Second)
+	book, err := client.Market.GetOrderBook("BTCUSDT", 0)
+	if err != nil {
+		t.Fatalf("GetOrderBook: %v", err)
+	}
+
+	prices := func(levels []mudrex.OrderBookLevel) []string {
+		out := make([]string, len(levels))
+		for i, l := range levels {
+			out[i] = l.Price
+		}
+		return out
+	}
+	if got, want := prices(book.Bids), []string{"100", "99.5", "98"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bids = %v, want %v", got, want)
+	}
+	if got, want := prices(book.Asks), []string{"100.5", "101", "102"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("asks = %v, want %v", got, want)
+	}
+}
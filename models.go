@@ -44,6 +44,8 @@ const (
 
 // Wallet Models
 type WalletBalance struct {
+	RawFields `json:"-"`
+	
 	Total             string `json:"total"`
 	Withdrawable      string `json:"withdrawable"`
 	Invested          string `json:"invested"`
@@ -54,18 +56,24 @@ type WalletBalance struct {
 }
 
 type FuturesBalance struct {
+	RawFields `json:"-"`
+	
 	Balance        string `json:"balance"`
 	LockedAmount   string `json:"locked_amount"`
 	FirstTimeUser  bool   `json:"first_time_user"`
 }
 
 type TransferResult struct {
+	RawFields `json:"-"`
+	
 	TransactionID string `json:"transaction_id"`
 	Success       bool   `json:"success"`
 }
 
 // Asset Models
 type Asset struct {
+	RawFields `json:"-"`
+	
 	AssetID       string `json:"asset_id"`
 	Symbol        string `json:"symbol"`
 	BaseCurrency  string `json:"base_currency"`
@@ -90,6 +98,8 @@ type AssetListResponse struct {
 
 // Leverage Models
 type Leverage struct {
+	RawFields `json:"-"`
+	
 	AssetID    string     `json:"asset_id"`
 	Leverage   string     `json:"leverage"`
 	MarginType MarginType `json:"margin_type"`
@@ -108,6 +118,8 @@ type OrderRequest struct {
 }
 
 type Order struct {
+	RawFields `json:"-"`
+	
 	OrderID         string        `json:"order_id"`
 	Symbol          string        `json:"symbol"`
 	AssetID         string        `json:"asset_id"`
@@ -121,13 +133,15 @@ type Order struct {
 	Leverage        string        `json:"leverage"`
 	StopLossPrice   *string       `json:"stoploss_price,omitempty"`
 	TakeProfitPrice *string       `json:"takeprofit_price,omitempty"`
-	CreatedAt       time.Time     `json:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at"`
+	CreatedAt       Timestamp     `json:"created_at"`
+	UpdatedAt       Timestamp     `json:"updated_at"`
 	ReduceOnly      bool          `json:"reduce_only"`
 }
 
 // Position Models
 type Position struct {
+	RawFields `json:"-"`
+	
 	PositionID      string         `json:"position_id"`
 	Symbol          string         `json:"symbol"`
 	AssetID         string         `json:"asset_id"`
@@ -143,8 +157,8 @@ type Position struct {
 	MarkPrice       string         `json:"mark_price"`
 	StopLoss        *string        `json:"stop_loss,omitempty"`
 	TakeProfit      *string        `json:"take_profit,omitempty"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
+	CreatedAt       Timestamp      `json:"created_at"`
+	UpdatedAt       Timestamp      `json:"updated_at"`
 }
 
 // PnLPercentage calculates the P&L percentage
@@ -155,25 +169,41 @@ func (p *Position) PnLPercentage() (string, error) {
 
 // RiskOrder represents a stop loss or take profit order
 type RiskOrder struct {
+	RawFields `json:"-"`
+	
 	OrderID         string    `json:"order_id"`
 	PositionID      string    `json:"position_id"`
-	OrderType       string    `json:"order_type"` // "STOP_LOSS" or "TAKE_PROFIT"
+	OrderType       RiskOrderType `json:"order_type"`
 	TriggerPrice    string    `json:"trigger_price"`
 	ExecutionPrice  *string   `json:"execution_price,omitempty"`
 	Status          string    `json:"status"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	CreatedAt       Timestamp `json:"created_at"`
+	UpdatedAt       Timestamp `json:"updated_at"`
 }
 
 // Fee Models
 type FeeRecord struct {
+	RawFields `json:"-"`
+	
 	AssetID    string    `json:"asset_id"`
 	Symbol     string    `json:"symbol"`
 	FeeAmount  string    `json:"fee_amount"`
 	FeeRate    string    `json:"fee_rate"`
-	TradeType  string    `json:"trade_type"` // "MAKER" or "TAKER"
+	TradeType  TradeType `json:"trade_type"`
 	OrderID    string    `json:"order_id"`
-	CreatedAt  time.Time `json:"created_at"`
+	CreatedAt  Timestamp `json:"created_at"`
+}
+
+// Candle Models
+
+// Candle is a single OHLCV bar with prices parsed to floats for computation
+type Candle struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
 }
 
 // APIResponse wraps API responses
@@ -0,0 +1,195 @@
+package mudrextest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// MarketServer is a local REST stand-in for the market data endpoints. Pass
+// its URL as the client's base URL and seed it with the Set methods; klines,
+// trades and funding rates honour the start_time, end_time and limit
+// parameters the SDK sends.
+type MarketServer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	tickers      map[string]mudrex.Ticker
+	orderBooks   map[string]mudrex.OrderBook
+	klines       map[string][]mudrex.Kline
+	trades       map[string][]mudrex.Trade
+	fundingRates map[string][]mudrex.FundingRate
+	requests     []*http.Request
+}
+
+// NewMarketServer starts a market data stand-in listening on a local port
+func NewMarketServer() *MarketServer {
+	s := &MarketServer{
+		tickers:      make(map[string]mudrex.Ticker),
+		orderBooks:   make(map[string]mudrex.OrderBook),
+		klines:       make(map[string][]mudrex.Kline),
+		trades:       make(map[string][]mudrex.Trade),
+		fundingRates: make(map[string][]mudrex.FundingRate),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// SetTicker sets the ticker served for ticker.AssetID
+func (s *MarketServer) SetTicker(ticker mudrex.Ticker) {
+	s.mu.Lock()
+	s.tickers[ticker.AssetID] = ticker
+	s.mu.Unlock()
+}
+
+// SetOrderBook sets the order book served for book.AssetID
+func (s *MarketServer) SetOrderBook(book mudrex.OrderBook) {
+	s.mu.Lock()
+	s.orderBooks[book.AssetID] = book
+	s.mu.Unlock()
+}
+
+// SetKlines sets the candles served for assetID and interval, oldest first
+func (s *MarketServer) SetKlines(assetID string, interval mudrex.Interval, klines []mudrex.Kline) {
+	s.mu.Lock()
+	s.klines[assetID+"/"+string(interval)] = klines
+	s.mu.Unlock()
+}
+
+// SetTrades sets the trades served for assetID, oldest first
+func (s *MarketServer) SetTrades(assetID string, trades []mudrex.Trade) {
+	s.mu.Lock()
+	s.trades[assetID] = trades
+	s.mu.Unlock()
+}
+
+// SetFundingRates sets the funding history served for assetID, oldest first
+func (s *MarketServer) SetFundingRates(assetID string, rates []mudrex.FundingRate) {
+	s.mu.Lock()
+	s.fundingRates[assetID] = rates
+	s.mu.Unlock()
+}
+
+// Requests returns every request received so far
+func (s *MarketServer) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := make([]*http.Request, len(s.requests))
+	copy(requests, s.requests)
+	return requests
+}
+
+func (s *MarketServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 2 && parts[0] == "futures" && parts[1] == "tickers" {
+		tickers := make([]mudrex.Ticker, 0, len(s.tickers))
+		for _, t := range s.tickers {
+			tickers = append(tickers, t)
+		}
+		writeData(w, tickers)
+		return
+	}
+	if len(parts) != 3 || parts[0] != "futures" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	assetID, query := parts[1], r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	start, end := parseMillis(query.Get("start_time")), parseMillis(query.Get("end_time"))
+
+	switch parts[2] {
+	case "ticker":
+		if t, ok := s.tickers[assetID]; ok {
+			writeData(w, t)
+			return
+		}
+	case "orderbook":
+		if book, ok := s.orderBooks[assetID]; ok {
+			if depth, _ := strconv.Atoi(query.Get("depth")); depth > 0 {
+				book.Bids = head(book.Bids, depth)
+				book.Asks = head(book.Asks, depth)
+			}
+			writeData(w, book)
+			return
+		}
+	case "klines":
+		interval := query.Get("interval")
+		if interval == "" {
+			interval = string(mudrex.Interval1m)
+		}
+		if klines, ok := s.klines[assetID+"/"+interval]; ok {
+			writeData(w, window(klines, start, end, limit, func(k mudrex.Kline) time.Time { return k.OpenTime.Time }))
+			return
+		}
+	case "trades":
+		if trades, ok := s.trades[assetID]; ok {
+			writeData(w, window(trades, start, end, limit, func(t mudrex.Trade) time.Time { return t.Timestamp.Time }))
+			return
+		}
+	case "funding-rates":
+		if rates, ok := s.fundingRates[assetID]; ok {
+			writeData(w, window(rates, start, end, limit, func(f mudrex.FundingRate) time.Time { return f.FundingTime.Time }))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "not found")
+}
+
+// window keeps the items within [start, end] and, with a limit, the latest
+// limit of them
+func window[T any](items []T, start, end time.Time, limit int, at func(T) time.Time) []T {
+	out := make([]T, 0, len(items))
+	for _, item := range items {
+		t := at(item)
+		if (!start.IsZero() && t.Before(start)) || (!end.IsZero() && t.After(end)) {
+			continue
+		}
+		out = append(out, item)
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
+
+func head(levels []mudrex.OrderBookLevel, n int) []mudrex.OrderBookLevel {
+	if len(levels) > n {
+		return levels[:n]
+	}
+	return levels
+}
+
+func parseMillis(s string) time.Time {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+func writeData(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": data})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": message})
+}
@@ -0,0 +1,175 @@
+// Package mudrextest provides local stand-ins for Mudrex services so code
+// built on the SDK can be exercised without network access.
+package mudrextest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+	"github.com/DecentralizedJM/mudrex-go-sdk/internal/wsconn"
+)
+
+// StreamServer is a local WebSocket stand-in for the streaming endpoint. It
+// records subscribe messages and lets callers push updates to connected clients.
+type StreamServer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	conns         map[*wsconn.Conn]bool
+	subscriptions []mudrex.StreamMessage
+	secrets       []string
+	reject        bool
+
+	subscribed chan mudrex.StreamMessage
+}
+
+// NewStreamServer starts a stream stand-in listening on a local port
+func NewStreamServer() *StreamServer {
+	s := &StreamServer{
+		conns:      make(map[*wsconn.Conn]bool),
+		subscribed: make(chan mudrex.StreamMessage, 64),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// WebSocketURL returns the ws:// URL to pass as StreamConfig.URL
+func (s *StreamServer) WebSocketURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// Subscribed receives every subscribe message clients send
+func (s *StreamServer) Subscribed() <-chan mudrex.StreamMessage {
+	return s.subscribed
+}
+
+// Subscriptions returns all subscribe messages received so far
+func (s *StreamServer) Subscriptions() []mudrex.StreamMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := make([]mudrex.StreamMessage, len(s.subscriptions))
+	copy(subs, s.subscriptions)
+	return subs
+}
+
+// Secrets returns the X-Authentication values clients connected with
+func (s *StreamServer) Secrets() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets := make([]string, len(s.secrets))
+	copy(secrets, s.secrets)
+	return secrets
+}
+
+// Connections returns the number of currently connected clients
+func (s *StreamServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// Publish sends an update on channel to every connected client
+func (s *StreamServer) Publish(channel mudrex.StreamChannel, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	frame, err := json.Marshal(mudrex.StreamMessage{Channel: channel, Data: payload})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	conns := make([]*wsconn.Conn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+
+	for _, conn := range conns {
+		if err := conn.WriteMessage(wsconn.OpText, frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropConnections closes every client connection, forcing reconnects
+func (s *StreamServer) DropConnections() {
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = make(map[*wsconn.Conn]bool)
+	s.mu.Unlock()
+
+	for conn := range conns {
+		conn.Close()
+	}
+}
+
+// SetRejectConnections makes the server refuse WebSocket upgrades, which
+// pushes clients onto their polling fallback
+func (s *StreamServer) SetRejectConnections(reject bool) {
+	s.mu.Lock()
+	s.reject = reject
+	s.mu.Unlock()
+}
+
+func (s *StreamServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	reject := s.reject
+	s.mu.Unlock()
+	if reject {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	conn, err := wsconn.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.conns[conn] = true
+	s.secrets = append(s.secrets, r.Header.Get("X-Authentication"))
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg mudrex.StreamMessage
+		if json.Unmarshal(data, &msg) != nil || msg.Op != "subscribe" {
+			continue
+		}
+
+		s.mu.Lock()
+		s.subscriptions = append(s.subscriptions, msg)
+		s.mu.Unlock()
+
+		select {
+		case s.subscribed <- msg:
+		default:
+		}
+	}
+}
+
+// Close disconnects clients and shuts the server down
+func (s *StreamServer) Close() {
+	s.DropConnections()
+	s.Server.Close()
+}
@@ -1,11 +1,8 @@
 package mudrex
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strconv"
 )
 
 // OrdersAPI handles order-related endpoints
@@ -15,29 +12,23 @@ type OrdersAPI struct {
 
 // Create creates a new order
 func (o *OrdersAPI) Create(assetID string, order *OrderRequest) (*Order, error) {
-	path := fmt.Sprintf("/futures/%s/order", assetID)
-	
-	jsonBody, err := json.Marshal(order)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-	
-	resp, err := o.client.Post(path, bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create order: %w", err)
-	}
-	
-	var apiResp APIResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-	
-	var result Order
-	if err := json.Unmarshal(apiResp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse order: %w", err)
-	}
-	
-	return &result, nil
+	return o.CreateWithKey("", assetID, order)
+}
+
+// CreateWithKey creates a new order, sending key as the idempotency key so a
+// retried request does not place a second order. An empty key sends none.
+func (o *OrdersAPI) CreateWithKey(key, assetID string, order *OrderRequest) (*Order, error) {
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
+	
+	return doPtr[Order](o.client, request{
+		method: "POST",
+		path:   fmt.Sprintf("/futures/%s/order", assetID),
+		body:   order,
+		header: idempotencyHeader(key),
+		op:     "create order",
+	})
 }
 
 // CreateMarketOrder creates a market order
@@ -67,121 +58,52 @@ func (o *OrdersAPI) CreateLimitOrder(assetID string, side OrderType, quantity, p
 
 // ListOpen retrieves all open orders
 func (o *OrdersAPI) ListOpen(assetID string) ([]Order, error) {
-	path := fmt.Sprintf("/futures/%s/orders", assetID)
-	
-	resp, err := o.client.Get(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list orders: %w", err)
-	}
-	
-	var apiResp APIResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-	
-	var orders []Order
-	if err := json.Unmarshal(apiResp.Data, &orders); err != nil {
-		return nil, fmt.Errorf("failed to parse orders: %w", err)
-	}
-	
-	return orders, nil
+	return do[[]Order](o.client, request{
+		method: "GET",
+		path:   fmt.Sprintf("/futures/%s/orders", assetID),
+		op:     "list orders",
+	})
 }
 
 // Get retrieves a specific order
 func (o *OrdersAPI) Get(assetID, orderID string) (*Order, error) {
-	path := fmt.Sprintf("/futures/%s/order/%s", assetID, orderID)
-	
-	resp, err := o.client.Get(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get order: %w", err)
-	}
-	
-	var apiResp APIResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-	
-	var order Order
-	if err := json.Unmarshal(apiResp.Data, &order); err != nil {
-		return nil, fmt.Errorf("failed to parse order: %w", err)
-	}
-	
-	return &order, nil
+	return doPtr[Order](o.client, request{
+		method: "GET",
+		path:   fmt.Sprintf("/futures/%s/order/%s", assetID, orderID),
+		op:     "get order",
+	})
 }
 
 // GetHistory retrieves order history with pagination
 func (o *OrdersAPI) GetHistory(assetID string, page, perPage int) ([]Order, error) {
-	params := url.Values{}
-	if page > 0 {
-		params.Set("page", strconv.Itoa(page))
-	}
-	if perPage > 0 {
-		params.Set("per_page", strconv.Itoa(perPage))
-	}
-	
-	path := fmt.Sprintf("/futures/%s/orders/history", assetID)
-	if len(params) > 0 {
-		path += "?" + params.Encode()
-	}
-	
-	resp, err := o.client.Get(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get order history: %w", err)
-	}
-	
-	var apiResp APIResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-	
-	var orders []Order
-	if err := json.Unmarshal(apiResp.Data, &orders); err != nil {
-		return nil, fmt.Errorf("failed to parse orders: %w", err)
-	}
-	
-	return orders, nil
+	return do[[]Order](o.client, request{
+		method: "GET",
+		path:   fmt.Sprintf("/futures/%s/orders/history", assetID),
+		query:  pageQuery(page, perPage),
+		op:     "get order history",
+	})
 }
 
 // Cancel cancels an order
 func (o *OrdersAPI) Cancel(assetID, orderID string) (bool, error) {
-	path := fmt.Sprintf("/futures/%s/order/%s", assetID, orderID)
-	
-	_, err := o.client.Delete(path, nil)
-	if err != nil {
-		return false, fmt.Errorf("failed to cancel order: %w", err)
-	}
-	
-	return true, nil
+	_, err := do[json.RawMessage](o.client, request{
+		method:  "DELETE",
+		path:    fmt.Sprintf("/futures/%s/order/%s", assetID, orderID),
+		op:      "cancel order",
+		discard: true,
+	})
+	return err == nil, err
 }
 
 // Amend modifies an existing order
 func (o *OrdersAPI) Amend(assetID, orderID, price, quantity string) (*Order, error) {
-	path := fmt.Sprintf("/futures/%s/order/%s", assetID, orderID)
-	
-	body := map[string]interface{}{
-		"price":    price,
-		"quantity": quantity,
-	}
-	
-	jsonBody, err := json.Marshal(body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-	
-	resp, err := o.client.Patch(path, bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to amend order: %w", err)
-	}
-	
-	var apiResp APIResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-	
-	var order Order
-	if err := json.Unmarshal(apiResp.Data, &order); err != nil {
-		return nil, fmt.Errorf("failed to parse order: %w", err)
-	}
-	
-	return &order, nil
+	return doPtr[Order](o.client, request{
+		method: "PATCH",
+		path:   fmt.Sprintf("/futures/%s/order/%s", assetID, orderID),
+		body: map[string]interface{}{
+			"price":    price,
+			"quantity": quantity,
+		},
+		op: "amend order",
+	})
 }
@@ -0,0 +1,52 @@
+package mudrex
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Paging defaults used by PageAll
+const (
+	DefaultPerPage  = 100
+	DefaultMaxPages = 1000
+)
+
+// ErrPageLimit is returned, wrapped, by PageAll when maxPages pages were
+// fetched without reaching the end of the history
+var ErrPageLimit = errors.New("page limit reached before the end of the history")
+
+// PageAll calls fetch with increasing page numbers until the history ends and
+// concatenates the results. It suits the GetHistory methods, e.g.
+// PageAll(0, 0, client.Fees.GetHistory). Zero perPage and maxPages use
+// DefaultPerPage and DefaultMaxPages.
+//
+// The history ends at an empty page or at a page shorter than an earlier one.
+// A short first page is not taken as the end, since a server may cap the page
+// size below perPage. If maxPages pages are fetched before the end, PageAll
+// returns them with an error wrapping ErrPageLimit rather than a silently
+// truncated history.
+func PageAll[T any](perPage, maxPages int, fetch func(page, perPage int) ([]T, error)) ([]T, error) {
+	if perPage <= 0 {
+		perPage = DefaultPerPage
+	}
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+
+	var all []T
+	pageSize := 0
+	for page := 1; page <= maxPages; page++ {
+		batch, err := fetch(page, perPage)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", page, err)
+		}
+		all = append(all, batch...)
+		if len(batch) == 0 || len(batch) < pageSize {
+			return all, nil
+		}
+		if len(batch) > pageSize {
+			pageSize = len(batch)
+		}
+	}
+	return all, fmt.Errorf("%w: fetched %d pages of up to %d", ErrPageLimit, maxPages, pageSize)
+}
@@ -0,0 +1,72 @@
+package mudrex
+
+import (
+	"errors"
+	"testing"
+)
+
+// pager serves total items, at most serverCap per page when set
+func pager(total, serverCap int, calls *int) func(page, perPage int) ([]int, error) {
+	return func(page, perPage int) ([]int, error) {
+		*calls++
+		if serverCap > 0 && perPage > serverCap {
+			perPage = serverCap
+		}
+		var batch []int
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			batch = append(batch, i)
+		}
+		return batch, nil
+	}
+}
+
+func TestPageAll(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		serverCap int
+		maxPages  int
+		want      int
+		calls     int
+		limit     bool
+	}{
+		{name: "empty", total: 0, want: 0, calls: 1},
+		{name: "short first page", total: 5, want: 5, calls: 2},
+		{name: "ends on a short page", total: 25, want: 25, calls: 3},
+		{name: "ends on a full page", total: 20, want: 20, calls: 3},
+		{name: "server caps the page size", total: 12, serverCap: 4, want: 12, calls: 4},
+		{name: "server caps and ends short", total: 10, serverCap: 4, want: 10, calls: 3},
+		{name: "page limit with a full last page", total: 50, maxPages: 2, want: 20, calls: 2, limit: true},
+		{name: "page limit at the end", total: 15, maxPages: 2, want: 15, calls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			got, err := PageAll(10, tt.maxPages, pager(tt.total, tt.serverCap, &calls))
+			if tt.limit != errors.Is(err, ErrPageLimit) || (!tt.limit && err != nil) {
+				t.Fatalf("PageAll error = %v, want page limit %v", err, tt.limit)
+			}
+			if len(got) != tt.want || calls != tt.calls {
+				t.Errorf("PageAll = %d items in %d calls, want %d in %d", len(got), calls, tt.want, tt.calls)
+			}
+			for i, v := range got {
+				if v != i {
+					t.Fatalf("item %d = %d, want items in order", i, v)
+				}
+			}
+		})
+	}
+}
+
+func TestPageAllError(t *testing.T) {
+	boom := errors.New("boom")
+	_, err := PageAll(10, 0, func(page, perPage int) ([]int, error) {
+		if page == 2 {
+			return nil, boom
+		}
+		return make([]int, perPage), nil
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("PageAll error = %v, want boom", err)
+	}
+}
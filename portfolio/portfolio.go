@@ -0,0 +1,246 @@
+// Package portfolio combines wallet, position and asset data into an
+// account-level snapshot of equity, margin and exposure.
+package portfolio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// Exposure aggregates the open positions in one asset
+type Exposure struct {
+	AssetID       string  `json:"asset_id"`
+	Symbol        string  `json:"symbol"`
+	Positions     int     `json:"positions"`
+	LongQuantity  float64 `json:"long_quantity"`
+	ShortQuantity float64 `json:"short_quantity"`
+	LongNotional  float64 `json:"long_notional"`
+	ShortNotional float64 `json:"short_notional"`
+	// NetNotional is long minus short notional
+	NetNotional   float64 `json:"net_notional"`
+	GrossNotional float64 `json:"gross_notional"`
+	Margin        float64 `json:"margin"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	// MaxLeverage is the asset's leverage ceiling, when asset metadata is known
+	MaxLeverage float64 `json:"max_leverage,omitempty"`
+}
+
+// PositionLoss identifies the position with the largest unrealized loss
+type PositionLoss struct {
+	PositionID    string  `json:"position_id"`
+	AssetID       string  `json:"asset_id"`
+	Symbol        string  `json:"symbol"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// Snapshot is an account-level risk summary
+type Snapshot struct {
+	Time          time.Time `json:"time"`
+	WalletBalance float64   `json:"wallet_balance"`
+	LockedAmount  float64   `json:"locked_amount"`
+	UnrealizedPnL float64   `json:"unrealized_pnl"`
+	// TotalEquity is the wallet balance plus unrealized P&L
+	TotalEquity float64 `json:"total_equity"`
+	UsedMargin  float64 `json:"used_margin"`
+	// FreeMargin is equity not committed as position margin
+	FreeMargin    float64 `json:"free_margin"`
+	LongNotional  float64 `json:"long_notional"`
+	ShortNotional float64 `json:"short_notional"`
+	NetNotional   float64 `json:"net_notional"`
+	GrossNotional float64 `json:"gross_notional"`
+	// EffectiveLeverage is gross notional divided by total equity
+	EffectiveLeverage     float64       `json:"effective_leverage"`
+	LargestUnrealizedLoss *PositionLoss `json:"largest_unrealized_loss,omitempty"`
+	// Exposures are sorted by gross notional, largest first
+	Exposures []Exposure `json:"exposures"`
+}
+
+// Build fetches the futures balance, open positions and metadata for every
+// asset held, and computes a snapshot
+func Build(client *mudrex.Client) (*Snapshot, error) {
+	balance, err := client.Wallet.GetFuturesBalance()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get futures balance: %w", err)
+	}
+
+	positions, err := client.Positions.ListOpen()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list positions: %w", err)
+	}
+
+	assets := make(map[string]mudrex.Asset)
+	for _, p := range positions {
+		if _, ok := assets[p.AssetID]; ok || p.AssetID == "" {
+			continue
+		}
+		asset, err := client.Assets.GetAsset(p.AssetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get asset %s: %w", p.AssetID, err)
+		}
+		assets[p.AssetID] = *asset
+	}
+
+	return Compute(balance, positions, assets)
+}
+
+// Compute builds a snapshot from already-fetched data. assets is keyed by
+// asset ID and may be nil. Position notional uses the mark price, falling
+// back to the entry price when no mark price is reported.
+func Compute(balance *mudrex.FuturesBalance, positions []mudrex.Position, assets map[string]mudrex.Asset) (*Snapshot, error) {
+	s := &Snapshot{Time: time.Now().UTC()}
+
+	var err error
+	if balance != nil {
+		if s.WalletBalance, err = mudrex.ParseDecimal("balance", balance.Balance); err != nil {
+			return nil, err
+		}
+		if s.LockedAmount, err = mudrex.ParseDecimal("locked_amount", balance.LockedAmount); err != nil {
+			return nil, err
+		}
+	}
+
+	exposures := make(map[string]*Exposure)
+	for _, p := range positions {
+		quantity, err := mudrex.ParseDecimal("quantity", p.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", p.PositionID, err)
+		}
+		price, err := mudrex.ParseDecimal("mark_price", p.MarkPrice)
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", p.PositionID, err)
+		}
+		if price == 0 {
+			if price, err = mudrex.ParseDecimal("entry_price", p.EntryPrice); err != nil {
+				return nil, fmt.Errorf("position %s: %w", p.PositionID, err)
+			}
+		}
+		margin, err := mudrex.ParseDecimal("margin", p.Margin)
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", p.PositionID, err)
+		}
+		unrealized, err := mudrex.ParseDecimal("unrealized_pnl", p.UnrealizedPnL)
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", p.PositionID, err)
+		}
+
+		key := p.AssetID
+		if key == "" {
+			key = p.Symbol
+		}
+		e, ok := exposures[key]
+		if !ok {
+			e = &Exposure{AssetID: p.AssetID, Symbol: p.Symbol}
+			if asset, ok := assets[p.AssetID]; ok {
+				if e.Symbol == "" {
+					e.Symbol = asset.Symbol
+				}
+				e.MaxLeverage, _ = mudrex.ParseDecimal("max_leverage", asset.MaxLeverage)
+			}
+			exposures[key] = e
+		}
+
+		notional := math.Abs(quantity) * price
+		e.Positions++
+		if p.Side == mudrex.OrderTypeShort {
+			e.ShortQuantity += math.Abs(quantity)
+			e.ShortNotional += notional
+		} else {
+			e.LongQuantity += math.Abs(quantity)
+			e.LongNotional += notional
+		}
+		e.Margin += margin
+		e.UnrealizedPnL += unrealized
+
+		if unrealized < 0 && (s.LargestUnrealizedLoss == nil || unrealized < s.LargestUnrealizedLoss.UnrealizedPnL) {
+			s.LargestUnrealizedLoss = &PositionLoss{
+				PositionID:    p.PositionID,
+				AssetID:       p.AssetID,
+				Symbol:        p.Symbol,
+				UnrealizedPnL: unrealized,
+			}
+		}
+	}
+
+	s.Exposures = make([]Exposure, 0, len(exposures))
+	for _, e := range exposures {
+		e.NetNotional = e.LongNotional - e.ShortNotional
+		e.GrossNotional = e.LongNotional + e.ShortNotional
+
+		s.LongNotional += e.LongNotional
+		s.ShortNotional += e.ShortNotional
+		s.UsedMargin += e.Margin
+		s.UnrealizedPnL += e.UnrealizedPnL
+		s.Exposures = append(s.Exposures, *e)
+	}
+	sort.Slice(s.Exposures, func(i, j int) bool {
+		if s.Exposures[i].GrossNotional != s.Exposures[j].GrossNotional {
+			return s.Exposures[i].GrossNotional > s.Exposures[j].GrossNotional
+		}
+		return s.Exposures[i].AssetID < s.Exposures[j].AssetID
+	})
+
+	s.NetNotional = s.LongNotional - s.ShortNotional
+	s.GrossNotional = s.LongNotional + s.ShortNotional
+	s.TotalEquity = s.WalletBalance + s.UnrealizedPnL
+	s.FreeMargin = s.TotalEquity - s.UsedMargin
+	if s.TotalEquity > 0 {
+		s.EffectiveLeverage = s.GrossNotional / s.TotalEquity
+	}
+
+	return s, nil
+}
+
+// ComputeWithPrices is Compute with each position marked at the fresh price
+// in prices rather than the MarkPrice it was fetched with; unrealized P&L is
+// recomputed from that price. It fails with the cache's error, such as a
+// *mudrex.StalePriceError, rather than value a position at a stale price.
+func ComputeWithPrices(balance *mudrex.FuturesBalance, positions []mudrex.Position, assets map[string]mudrex.Asset, prices *mudrex.PriceCache) (*Snapshot, error) {
+	marked := make([]mudrex.Position, len(positions))
+	for i, p := range positions {
+		mark, err := prices.Mark(p.AssetID)
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", p.PositionID, err)
+		}
+		quantity, err := mudrex.ParseDecimal("quantity", p.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", p.PositionID, err)
+		}
+		entry, err := mudrex.ParseDecimal("entry_price", p.EntryPrice)
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", p.PositionID, err)
+		}
+
+		unrealized := (mark - entry) * math.Abs(quantity)
+		if p.Side == mudrex.OrderTypeShort {
+			unrealized = -unrealized
+		}
+		p.MarkPrice = strconv.FormatFloat(mark, 'f', -1, 64)
+		p.UnrealizedPnL = strconv.FormatFloat(unrealized, 'f', -1, 64)
+		marked[i] = p
+	}
+	return Compute(balance, marked, assets)
+}
+
+// WriteJSON writes the snapshot as indented JSON
+func (s *Snapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Exposure returns the exposure for an asset ID or symbol
+func (s *Snapshot) Exposure(asset string) (Exposure, bool) {
+	for _, e := range s.Exposures {
+		if e.AssetID == asset || e.Symbol == asset {
+			return e, true
+		}
+	}
+	return Exposure{}, false
+}
@@ -0,0 +1,308 @@
+package mudrex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoPrice is returned by PriceCache when it holds nothing for an asset
+var ErrNoPrice = errors.New("no cached price")
+
+// StalePriceError is returned by PriceCache when its data for an asset is
+// older than the staleness threshold
+type StalePriceError struct {
+	AssetID string
+	Age     time.Duration
+	MaxAge  time.Duration
+}
+
+func (e *StalePriceError) Error() string {
+	return fmt.Sprintf("price for %s is stale: %s old, limit %s", e.AssetID, e.Age.Round(time.Millisecond), e.MaxAge)
+}
+
+// CachedPrice is the last mark price seen for an asset
+type CachedPrice struct {
+	AssetID string
+	Mark    float64
+	// Time is when the price was valid: the exchange timestamp when the
+	// source carries one, otherwise when it was received
+	Time time.Time
+}
+
+type cachedBook struct {
+	book *OrderBook
+	time time.Time
+}
+
+// PriceCacheConfig configures a PriceCache
+type PriceCacheConfig struct {
+	// MaxAge beyond which cached data is refused (default 10s)
+	MaxAge time.Duration
+	// PollInterval between ticker polls in Poll (default 2s)
+	PollInterval time.Duration
+	// OrderBookDepth, when positive, makes Poll also fetch each asset's
+	// order book to that depth. Otherwise books are only cached when the
+	// caller passes them to UpdateOrderBook.
+	OrderBookDepth int
+	// MaxBackoff caps the poll interval after repeated rate limit errors
+	// (default 1m)
+	MaxBackoff time.Duration
+	// OnError receives poll errors; Poll keeps running
+	OnError func(error)
+}
+
+// PriceCache holds the latest mark price and order book per asset, fed by
+// polling, a Stream or the caller. Reads fail with a StalePriceError rather
+// than serve data older than MaxAge, so its sizing and estimate methods,
+// TrailingStop and portfolio.ComputeWithPrices never act on a stale
+// MarkPrice; helpers given prices directly cannot offer that guarantee. It is
+// safe for concurrent use.
+type PriceCache struct {
+	config PriceCacheConfig
+	now    func() time.Time
+
+	mu     sync.RWMutex
+	prices map[string]CachedPrice
+	books  map[string]cachedBook
+}
+
+// NewPriceCache creates an empty price cache
+func NewPriceCache(config PriceCacheConfig) *PriceCache {
+	if config.MaxAge <= 0 {
+		config.MaxAge = 10 * time.Second
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Minute
+	}
+
+	return &PriceCache{
+		config: config,
+		now:    time.Now,
+		prices: make(map[string]CachedPrice),
+		books:  make(map[string]cachedBook),
+	}
+}
+
+// MaxAge returns the staleness threshold
+func (c *PriceCache) MaxAge() time.Duration {
+	return c.config.MaxAge
+}
+
+// Set records mark as the price of assetID at time at. Updates older than
+// the cached price are ignored, so out-of-order sources cannot roll it back.
+func (c *PriceCache) Set(assetID string, mark float64, at time.Time) {
+	if mark <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if current, ok := c.prices[assetID]; ok && at.Before(current.Time) {
+		return
+	}
+	c.prices[assetID] = CachedPrice{AssetID: assetID, Mark: mark, Time: at}
+}
+
+// UpdateTicker records the ticker's mark price at its timestamp
+func (c *PriceCache) UpdateTicker(t *Ticker) error {
+	mark, err := ParseDecimal("mark_price", t.MarkPrice)
+	if err != nil {
+		return err
+	}
+	c.Set(t.AssetID, mark, c.sourceTime(t.Timestamp))
+	return nil
+}
+
+// UpdatePosition records the position's mark price as of now; a position's
+// UpdatedAt tracks changes to the position, not to its mark price
+func (c *PriceCache) UpdatePosition(p *Position) error {
+	mark, err := ParseDecimal("mark_price", p.MarkPrice)
+	if err != nil {
+		return err
+	}
+	c.Set(p.AssetID, mark, c.now())
+	return nil
+}
+
+// UpdateOrderBook records book at its timestamp
+func (c *PriceCache) UpdateOrderBook(book *OrderBook) {
+	at := c.sourceTime(book.Timestamp)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if current, ok := c.books[book.AssetID]; ok && at.Before(current.time) {
+		return
+	}
+	c.books[book.AssetID] = cachedBook{book: book, time: at}
+}
+
+// Price returns the cached price of assetID if it is fresh
+func (c *PriceCache) Price(assetID string) (CachedPrice, error) {
+	c.mu.RLock()
+	price, ok := c.prices[assetID]
+	c.mu.RUnlock()
+
+	if !ok {
+		return CachedPrice{}, fmt.Errorf("%s: %w", assetID, ErrNoPrice)
+	}
+	if err := c.checkAge(assetID, price.Time); err != nil {
+		return CachedPrice{}, err
+	}
+	return price, nil
+}
+
+// Mark returns the cached mark price of assetID if it is fresh
+func (c *PriceCache) Mark(assetID string) (float64, error) {
+	price, err := c.Price(assetID)
+	if err != nil {
+		return 0, err
+	}
+	return price.Mark, nil
+}
+
+// OrderBook returns the cached order book of assetID if it is fresh. The
+// book is shared; do not modify it.
+func (c *PriceCache) OrderBook(assetID string) (*OrderBook, error) {
+	c.mu.RLock()
+	cached, ok := c.books[assetID]
+	c.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%s order book: %w", assetID, ErrNoPrice)
+	}
+	if err := c.checkAge(assetID, cached.time); err != nil {
+		return nil, err
+	}
+	return cached.book, nil
+}
+
+// Poll fetches the ticker of each asset, and its order book when
+// OrderBookDepth is set, every PollInterval until ctx is cancelled, backing
+// off on rate limits. Errors go to OnError.
+func (c *PriceCache) Poll(ctx context.Context, client *Client, assetIDs ...string) error {
+	schedule := newPollSchedule(c.config.PollInterval, c.config.MaxBackoff)
+	for {
+		var pollErr error
+		for _, assetID := range assetIDs {
+			ticker, err := client.Market.GetTicker(assetID)
+			if err == nil {
+				if ticker.AssetID == "" {
+					ticker.AssetID = assetID
+				}
+				err = c.UpdateTicker(ticker)
+			}
+			if err != nil {
+				pollErr = err
+				c.report(fmt.Errorf("poll %s price: %w", assetID, err))
+			}
+			if c.config.OrderBookDepth <= 0 {
+				continue
+			}
+			book, err := client.Market.GetOrderBook(assetID, c.config.OrderBookDepth)
+			if err != nil {
+				pollErr = err
+				c.report(fmt.Errorf("poll %s order book: %w", assetID, err))
+				continue
+			}
+			if book.AssetID == "" {
+				book.AssetID = assetID
+			}
+			c.UpdateOrderBook(book)
+		}
+		schedule.done(pollErr)
+
+		timer := time.NewTimer(time.Until(schedule.next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// Feed records the mark price of every position update on sub until ctx is
+// cancelled or sub is closed
+func (c *PriceCache) Feed(ctx context.Context, sub *Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case u, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if u.Position != nil {
+				if err := c.UpdatePosition(u.Position); err != nil {
+					c.report(err)
+				}
+			}
+		}
+	}
+}
+
+// SizeByRisk is SizeByRisk with the entry taken from the fresh mark price
+func (c *PriceCache) SizeByRisk(asset *Asset, p RiskSizing) (*PositionSize, error) {
+	mark, err := c.Mark(asset.AssetID)
+	if err != nil {
+		return nil, err
+	}
+	p.Entry = mark
+	return SizeByRisk(asset, p)
+}
+
+// SizeByATR is SizeByATR with the entry taken from the fresh mark price
+func (c *PriceCache) SizeByATR(asset *Asset, p ATRSizing) (*PositionSize, error) {
+	mark, err := c.Mark(asset.AssetID)
+	if err != nil {
+		return nil, err
+	}
+	p.Entry = mark
+	return SizeByATR(asset, p)
+}
+
+// SizeByNotional is SizeByNotional at the fresh mark price
+func (c *PriceCache) SizeByNotional(asset *Asset, notional, leverage float64) (*PositionSize, error) {
+	mark, err := c.Mark(asset.AssetID)
+	if err != nil {
+		return nil, err
+	}
+	return SizeByNotional(asset, notional, mark, leverage)
+}
+
+// EstimateRequest is EstimateRequest with market orders estimated at the
+// fresh mark price
+func (c *PriceCache) EstimateRequest(asset *Asset, req *OrderRequest, balance *FuturesBalance) (*OrderEstimate, error) {
+	mark, err := c.Mark(asset.AssetID)
+	if err != nil {
+		return nil, err
+	}
+	return EstimateRequest(asset, req, mark, balance)
+}
+
+func (c *PriceCache) checkAge(assetID string, at time.Time) error {
+	if age := c.now().Sub(at); age > c.config.MaxAge {
+		return &StalePriceError{AssetID: assetID, Age: age, MaxAge: c.config.MaxAge}
+	}
+	return nil
+}
+
+// sourceTime is the exchange timestamp when present, otherwise now
+func (c *PriceCache) sourceTime(t Timestamp) time.Time {
+	if t.IsZero() {
+		return c.now()
+	}
+	return t.Time
+}
+
+func (c *PriceCache) report(err error) {
+	if c.config.OnError != nil {
+		c.config.OnError(err)
+	}
+}
@@ -0,0 +1,232 @@
+package mudrex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UnsuccessfulResponseError is returned when the API answers with a 2xx
+// status but reports "success": false in the envelope
+type UnsuccessfulResponseError struct {
+	*MudrexError
+	// Body is the raw response body
+	Body []byte
+}
+
+// DecodeError is returned when a response cannot be decoded. Body holds the
+// raw response for debugging.
+type DecodeError struct {
+	Body []byte
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to parse response: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// envelope is the wrapper around every API response. Success is a pointer so
+// that responses omitting it are not mistaken for failures.
+type envelope struct {
+	Success *bool           `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Error   *APIError       `json:"error"`
+}
+
+// request describes one API call for do
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	// body is marshalled to JSON when non-nil
+	body   interface{}
+	header http.Header
+	// op completes "failed to ..." in error messages
+	op string
+	// discard accepts an empty body or missing data, for actions whose
+	// result is not used
+	discard bool
+	// object rejects null data, for endpoints returning a single object
+	object bool
+}
+
+// Decoding failures for responses without a result
+var (
+	errEmptyBody   = errors.New("empty response body")
+	errMissingData = errors.New("response has no data")
+)
+
+// SetDebug enables debug logging to logger: response fields the SDK does not
+// map are reported for every call. Pass nil to disable.
+func (c *Client) SetDebug(logger *log.Logger) {
+	c.debug = logger
+}
+
+// do sends req, decodes the response envelope and returns its data as T.
+// HTTP errors keep their typed errors, success:false becomes an
+// UnsuccessfulResponseError, and decoding failures, including an empty body
+// or missing data, a DecodeError carrying the raw body.
+func do[T any](c *Client, req request) (T, error) {
+	var zero T
+
+	path := req.path
+	if len(req.query) > 0 {
+		path += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		payload, err := json.Marshal(req.body)
+		if err != nil {
+			return zero, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	raw, meta, err := c.doRequestWithHeader(req.method, path, body, req.header)
+	if err != nil {
+		return zero, fmt.Errorf("failed to %s: %w", req.op, err)
+	}
+
+	var env envelope
+	if len(bytes.TrimSpace(raw)) == 0 {
+		// Some actions answer with an empty body
+		if req.discard {
+			return zero, nil
+		}
+		return zero, fmt.Errorf("failed to %s: %w", req.op, &DecodeError{Body: raw, Err: errEmptyBody})
+	}
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return zero, fmt.Errorf("failed to %s: %w", req.op, &DecodeError{Body: raw, Err: err})
+	}
+	if env.Success != nil && !*env.Success {
+		failure := &MudrexError{Code: -1, Message: env.Message, Status: http.StatusOK}
+		if env.Error != nil {
+			failure.Code = env.Error.Code
+			failure.Message = env.Error.Message
+		}
+		return zero, fmt.Errorf("failed to %s: %w", req.op, &UnsuccessfulResponseError{MudrexError: failure, Body: raw})
+	}
+
+	null := bytes.Equal(bytes.TrimSpace(env.Data), []byte("null"))
+	if !req.discard && (len(env.Data) == 0 || (req.object && null)) {
+		return zero, fmt.Errorf("failed to %s: %w", req.op, &DecodeError{Body: raw, Err: errMissingData})
+	}
+
+	var out T
+	if len(env.Data) > 0 && !null {
+		if err := json.Unmarshal(env.Data, &out); err != nil {
+			return zero, fmt.Errorf("failed to %s: %w", req.op, &DecodeError{Body: raw, Err: err})
+		}
+	}
+
+	if c.retainRaw {
+		retainRaw(reflect.ValueOf(&out).Elem(), env.Data, meta)
+	}
+	if c.debug != nil {
+		if unknown := unknownFields(env.Data, reflect.TypeOf(out)); len(unknown) > 0 {
+			c.debug.Printf("mudrex: %s %s: unmapped response fields: %s", req.method, req.path, strings.Join(unknown, ", "))
+		}
+	}
+	return out, nil
+}
+
+// doPtr is do for endpoints returning a single object; a response without
+// one is a DecodeError
+func doPtr[T any](c *Client, req request) (*T, error) {
+	req.object = true
+	out, err := do[T](c, req)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// pageQuery builds the page and per_page parameters of history endpoints
+func pageQuery(page, perPage int) url.Values {
+	params := url.Values{}
+	if page > 0 {
+		params.Set("page", strconv.Itoa(page))
+	}
+	if perPage > 0 {
+		params.Set("per_page", strconv.Itoa(perPage))
+	}
+	return params
+}
+
+// unknownFields lists the keys of data, an object or array of objects, that
+// do not correspond to a json field of t
+func unknownFields(data json.RawMessage, t reflect.Type) []string {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || len(data) == 0 {
+		return nil
+	}
+
+	var objects []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &objects); err != nil {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil
+		}
+		objects = append(objects, object)
+	}
+
+	known := jsonFieldNames(t)
+	seen := make(map[string]bool)
+	var unknown []string
+	for _, object := range objects {
+		for key := range object {
+			if !known[strings.ToLower(key)] && !seen[key] {
+				seen[key] = true
+				unknown = append(unknown, t.Name()+"."+key)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// jsonFieldNames returns the JSON keys the encoding/json package maps onto t,
+// including those of embedded structs
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for embedded := range jsonFieldNames(f.Type) {
+				names[embedded] = true
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		// encoding/json matches keys case-insensitively
+		names[strings.ToLower(name)] = true
+	}
+	return names
+}
@@ -0,0 +1,235 @@
+package mudrex
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient returns a client for a server answering every request with
+// status and body
+func testClient(t *testing.T, status int, body string) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClientWithConfig("secret", server.URL, 5*time.Second)
+	client.SetRateLimit(1000)
+	return client
+}
+
+func TestDoResponses(t *testing.T) {
+	const asset = `{"asset_id": "BTCUSDT", "symbol": "BTCUSDT"}`
+
+	// Each call reports the error it returned and, on success, the asset IDs
+	// it decoded
+	type call func(c *Client) ([]string, error)
+	object := func(c *Client) ([]string, error) {
+		a, err := doPtr[Asset](c, request{method: http.MethodGet, path: "/asset", op: "get asset"})
+		if err != nil {
+			return nil, err
+		}
+		return []string{a.AssetID}, nil
+	}
+	list := func(c *Client) ([]string, error) {
+		assets, err := do[[]Asset](c, request{method: http.MethodGet, path: "/assets", op: "list assets"})
+		ids := []string{}
+		for _, a := range assets {
+			ids = append(ids, a.AssetID)
+		}
+		return ids, err
+	}
+	discard := func(c *Client) ([]string, error) {
+		_, err := do[struct{}](c, request{method: http.MethodDelete, path: "/asset", op: "delete asset", discard: true})
+		return []string{}, err
+	}
+
+	tests := []struct {
+		name   string
+		call   call
+		status int
+		body   string
+		want   []string
+		// check inspects the error; nil expects success
+		check func(t *testing.T, err error)
+	}{
+		{name: "object", call: object, body: `{"success": true, "data": ` + asset + `}`, want: []string{"BTCUSDT"}},
+		{name: "list", call: list, body: `{"success": true, "data": [` + asset + `]}`, want: []string{"BTCUSDT"}},
+		{name: "envelope without success", call: object, body: `{"data": ` + asset + `}`, want: []string{"BTCUSDT"}},
+		{name: "null list", call: list, body: `{"success": true, "data": null}`, want: []string{}},
+		{name: "discard with data", call: discard, body: `{"success": true, "data": {"ok": true}}`, want: []string{}},
+		{name: "discard with empty body", call: discard, status: http.StatusNoContent, want: []string{}},
+		{name: "discard without data", call: discard, body: `{"success": true}`, want: []string{}},
+		{
+			name: "success false with message", call: object,
+			body:  `{"success": false, "message": "asset delisted"}`,
+			check: unsuccessful(-1, "asset delisted"),
+		},
+		{
+			name: "success false with error object", call: list,
+			body:  `{"success": false, "message": "ignored", "error": {"code": 1002, "message": "insufficient balance"}}`,
+			check: unsuccessful(1002, "insufficient balance"),
+		},
+		{
+			name: "success false when discarding", call: discard,
+			body:  `{"success": false, "message": "order already closed"}`,
+			check: unsuccessful(-1, "order already closed"),
+		},
+		{name: "empty body", call: object, check: decodeFailure(errEmptyBody)},
+		{name: "whitespace body", call: list, body: " \n", check: decodeFailure(errEmptyBody)},
+		{name: "missing data", call: object, body: `{"success": true}`, check: decodeFailure(errMissingData)},
+		{name: "missing list data", call: list, body: `{"success": true}`, check: decodeFailure(errMissingData)},
+		{name: "null object", call: object, body: `{"success": true, "data": null}`, check: decodeFailure(errMissingData)},
+		{name: "non-JSON body", call: object, body: `<html>bad gateway</html>`, check: decodeFailure(nil)},
+		{name: "data of the wrong type", call: list, body: `{"success": true, "data": {"asset_id": 1}}`, check: decodeFailure(nil)},
+		{
+			name: "HTTP error keeps its type", call: object, status: http.StatusNotFound,
+			body: `{"success": false, "message": "no such asset"}`,
+			check: func(t *testing.T, err error) {
+				var notFound *NotFoundError
+				if !errors.As(err, &notFound) || notFound.Message != "no such asset" {
+					t.Errorf("error = %v, want a NotFoundError", err)
+				}
+				var decode *DecodeError
+				if errors.As(err, &decode) {
+					t.Errorf("error = %v, want no DecodeError", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			got, err := tt.call(testClient(t, status, tt.body))
+			if tt.check != nil {
+				if err == nil {
+					t.Fatalf("got %v, want error", got)
+				}
+				if !strings.HasPrefix(err.Error(), "failed to ") {
+					t.Errorf("error %q does not name the operation", err)
+				}
+				tt.check(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// unsuccessful expects an UnsuccessfulResponseError with code and message
+func unsuccessful(code int, message string) func(*testing.T, error) {
+	return func(t *testing.T, err error) {
+		t.Helper()
+		var failure *UnsuccessfulResponseError
+		if !errors.As(err, &failure) {
+			t.Fatalf("error = %v, want an UnsuccessfulResponseError", err)
+		}
+		if failure.Code != code || failure.Message != message || failure.Status != http.StatusOK {
+			t.Errorf("error = code %d, message %q, status %d; want %d, %q, 200",
+				failure.Code, failure.Message, failure.Status, code, message)
+		}
+		if !json.Valid(failure.Body) {
+			t.Errorf("Body = %q, want the raw response", failure.Body)
+		}
+	}
+}
+
+// decodeFailure expects a DecodeError carrying the body, wrapping cause when
+// it is set
+func decodeFailure(cause error) func(*testing.T, error) {
+	return func(t *testing.T, err error) {
+		t.Helper()
+		var decode *DecodeError
+		if !errors.As(err, &decode) {
+			t.Fatalf("error = %v, want a DecodeError", err)
+		}
+		if cause != nil && !errors.Is(err, cause) {
+			t.Errorf("error = %v, want it to wrap %v", err, cause)
+		}
+		var failure *UnsuccessfulResponseError
+		if errors.As(err, &failure) {
+			t.Errorf("error = %v, want no UnsuccessfulResponseError", err)
+		}
+	}
+}
+
+func TestDoDecodeErrorBody(t *testing.T) {
+	const body = `<html>bad gateway</html>`
+	_, err := doPtr[Asset](testClient(t, http.StatusOK, body), request{method: http.MethodGet, path: "/asset", op: "get asset"})
+
+	var decode *DecodeError
+	if !errors.As(err, &decode) || string(decode.Body) != body {
+		t.Fatalf("error = %v, want a DecodeError with the raw body", err)
+	}
+	var syntax *json.SyntaxError
+	if !errors.As(err, &syntax) {
+		t.Errorf("error = %v, want it to wrap the JSON syntax error", err)
+	}
+}
+
+func TestDoRawFields(t *testing.T) {
+	const data = `{"asset_id": "BTCUSDT", "symbol": "BTCUSDT", "funding_interval": 8}`
+
+	tests := []struct {
+		name   string
+		retain bool
+	}{
+		{"retained", true},
+		{"not retained", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := testClient(t, http.StatusOK, `{"success": true, "data": [`+data+`]}`)
+			client.SetRetainRaw(tt.retain)
+
+			assets, err := do[[]Asset](client, request{method: http.MethodGet, path: "/assets", op: "list assets"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(assets) != 1 {
+				t.Fatalf("got %d assets, want 1", len(assets))
+			}
+			a := assets[0]
+
+			if !tt.retain {
+				if a.Raw != nil || a.Extra != nil || a.Response != nil {
+					t.Errorf("RawFields = %+v, want empty", a.RawFields)
+				}
+				return
+			}
+			if string(a.Raw) != data {
+				t.Errorf("Raw = %s, want %s", a.Raw, data)
+			}
+			if len(a.Extra) != 1 {
+				t.Errorf("Extra = %v, want only funding_interval", a.Extra)
+			}
+			var interval int
+			if ok, err := a.ExtraField("funding_interval", &interval); !ok || err != nil || interval != 8 {
+				t.Errorf("ExtraField = %v, %v, %d; want true, nil, 8", ok, err, interval)
+			}
+			if ok, _ := a.ExtraField("missing", &interval); ok {
+				t.Error("ExtraField reported a missing field")
+			}
+			if a.Response == nil || a.Response.Status != http.StatusOK || a.Response.Path != "/assets" ||
+				a.Response.RequestID != "req-1" {
+				t.Errorf("Response = %+v, want status 200 for /assets with request ID req-1", a.Response)
+			}
+		})
+	}
+}
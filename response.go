@@ -0,0 +1,135 @@
+package mudrex
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// requestIDHeaders are the response headers checked, in order, for a request
+// ID to quote to support
+var requestIDHeaders = []string{"X-Request-Id", "X-Amzn-Requestid", "X-Correlation-Id", "Cf-Ray"}
+
+// ResponseMeta describes the HTTP exchange behind an API call
+type ResponseMeta struct {
+	Method string
+	Path   string
+	// Status is zero when no response was received
+	Status    int
+	Header    http.Header
+	RequestID string
+	// Latency covers the HTTP round trip, excluding rate limiter waits
+	Latency time.Duration
+}
+
+// RawFields is embedded in returned models. It is only filled when raw
+// retention is enabled with Client.SetRetainRaw.
+type RawFields struct {
+	// Raw is the model's JSON exactly as received
+	Raw json.RawMessage `json:"-"`
+	// Extra holds the fields of Raw the SDK does not map, so fields added by
+	// the API are usable before the SDK is updated
+	Extra map[string]json.RawMessage `json:"-"`
+	// Response describes the call that returned the model; models decoded
+	// from the same list share it
+	Response *ResponseMeta `json:"-"`
+}
+
+func (r *RawFields) setRaw(raw json.RawMessage, extra map[string]json.RawMessage, meta *ResponseMeta) {
+	r.Raw, r.Extra, r.Response = raw, extra, meta
+}
+
+// ExtraField decodes the unmapped field name into v. It reports false when
+// the field is absent or raw retention is disabled.
+func (r *RawFields) ExtraField(name string, v interface{}) (bool, error) {
+	raw, ok := r.Extra[name]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// rawRetainer is implemented by models embedding RawFields
+type rawRetainer interface {
+	setRaw(raw json.RawMessage, extra map[string]json.RawMessage, meta *ResponseMeta)
+}
+
+// SetRetainRaw makes returned models keep their raw JSON, unmapped fields
+// and response metadata in their embedded RawFields. It is off by default
+// to avoid holding every response body in memory.
+func (c *Client) SetRetainRaw(retain bool) {
+	c.retainRaw = retain
+}
+
+// OnResponse registers fn to receive the metadata of every HTTP exchange,
+// including failed ones, e.g. for latency metrics or logging request IDs.
+// Pass nil to remove it.
+func (c *Client) OnResponse(fn func(*ResponseMeta)) {
+	c.onResponse = fn
+}
+
+// newResponseMeta records the parts of resp callers care about
+func newResponseMeta(method, path string, resp *http.Response, latency time.Duration) *ResponseMeta {
+	meta := &ResponseMeta{Method: method, Path: path, Latency: latency}
+	if resp == nil {
+		return meta
+	}
+	meta.Status = resp.StatusCode
+	meta.Header = resp.Header.Clone()
+	for _, name := range requestIDHeaders {
+		if id := resp.Header.Get(name); id != "" {
+			meta.RequestID = id
+			break
+		}
+	}
+	return meta
+}
+
+// retainRaw fills the RawFields of v, a model or slice of models decoded
+// from data
+func retainRaw(v reflect.Value, data json.RawMessage, meta *ResponseMeta) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			retainRaw(v.Elem(), data, meta)
+		}
+	case reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil || len(items) != v.Len() {
+			return
+		}
+		for i, item := range items {
+			retainRaw(v.Index(i), item, meta)
+		}
+	case reflect.Struct:
+		if !v.CanAddr() {
+			return
+		}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return
+		}
+		retainer, ok := v.Addr().Interface().(rawRetainer)
+		if !ok {
+			// Wrappers such as AssetListResponse hold models in their fields
+			for i := 0; i < v.NumField(); i++ {
+				f := v.Type().Field(i)
+				name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+				if raw, found := object[name]; found && f.IsExported() {
+					retainRaw(v.Field(i), raw, meta)
+				}
+			}
+			return
+		}
+		known := jsonFieldNames(v.Type())
+		extra := make(map[string]json.RawMessage)
+		for key, value := range object {
+			if !known[strings.ToLower(key)] {
+				extra[key] = value
+			}
+		}
+		retainer.setRaw(append(json.RawMessage(nil), data...), extra, meta)
+	}
+}
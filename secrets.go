@@ -0,0 +1,166 @@
+package mudrex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretProvider supplies the API secret. The client consults it before every
+// request, so providers that re-read their source pick up rotated secrets
+// without restarting.
+type SecretProvider interface {
+	Secret() (string, error)
+}
+
+// Invalidator is implemented by providers that cache their secret. The client
+// invalidates the cache and retries once when a request fails authentication.
+type Invalidator interface {
+	Invalidate()
+}
+
+// StaticSecret is a fixed secret
+type StaticSecret string
+
+// Secret returns the fixed secret
+func (s StaticSecret) Secret() (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("API secret is empty")
+	}
+	return string(s), nil
+}
+
+// EnvSecret reads the secret from the named environment variable on every request
+type EnvSecret string
+
+// Secret returns the current value of the environment variable
+func (e EnvSecret) Secret() (string, error) {
+	secret := strings.TrimSpace(os.Getenv(string(e)))
+	if secret == "" {
+		return "", fmt.Errorf("environment variable %s is not set", string(e))
+	}
+	return secret, nil
+}
+
+// FileSecret reads the secret from a file, re-reading it whenever the file's
+// modification time or size changes
+type FileSecret struct {
+	path string
+
+	mu      sync.Mutex
+	secret  string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileSecret creates a provider reading the secret from path. The file
+// must not be readable by group or others.
+func NewFileSecret(path string) *FileSecret {
+	return &FileSecret{path: path}
+}
+
+// Secret returns the file's contents with surrounding whitespace removed
+func (f *FileSecret) Secret() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat secret file: %w", err)
+	}
+	if mode := info.Mode().Perm(); runtime.GOOS != "windows" && mode&0o077 != 0 {
+		return "", fmt.Errorf("secret file %s has mode %04o; restrict it with chmod 600", f.path, mode)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.secret != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.secret, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", f.path)
+	}
+
+	f.secret = secret
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return secret, nil
+}
+
+// Invalidate forces the file to be re-read on the next request
+func (f *FileSecret) Invalidate() {
+	f.mu.Lock()
+	f.secret = ""
+	f.mu.Unlock()
+}
+
+// CommandSecret runs a helper command (e.g. a password manager CLI) and uses
+// its trimmed standard output as the secret, caching it for a TTL
+type CommandSecret struct {
+	name    string
+	args    []string
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu        sync.Mutex
+	secret    string
+	fetchedAt time.Time
+}
+
+// NewCommandSecret creates a provider that runs name with args. The result is
+// cached for ttl; a ttl of zero runs the command before every request.
+func NewCommandSecret(ttl time.Duration, name string, args ...string) *CommandSecret {
+	return &CommandSecret{
+		name:    name,
+		args:    args,
+		ttl:     ttl,
+		timeout: 30 * time.Second,
+	}
+}
+
+// Secret returns the cached secret or runs the command to fetch a fresh one
+func (c *CommandSecret) Secret() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.secret != "" && time.Since(c.fetchedAt) < c.ttl {
+		return c.secret, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.name, c.args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("secret command %s failed: %w: %s", c.name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	secret := strings.TrimSpace(stdout.String())
+	if secret == "" {
+		return "", fmt.Errorf("secret command %s produced no output", c.name)
+	}
+
+	c.secret = secret
+	c.fetchedAt = time.Now()
+	return secret, nil
+}
+
+// Invalidate forces the command to run again on the next request
+func (c *CommandSecret) Invalidate() {
+	c.mu.Lock()
+	c.secret = ""
+	c.mu.Unlock()
+}
@@ -0,0 +1,175 @@
+package mudrex
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/DecentralizedJM/mudrex-go-sdk/internal/wilder"
+)
+
+// PositionSize is an order size computed by one of the Size functions
+type PositionSize struct {
+	// Quantity is rounded down to the asset's QuantityStep and formatted for
+	// OrderRequest.Quantity
+	Quantity      string  `json:"quantity"`
+	QuantityValue float64 `json:"quantity_value"`
+	Price         float64 `json:"price"`
+	Leverage      float64 `json:"leverage"`
+	// Notional is quantity times price
+	Notional float64 `json:"notional"`
+	// Margin is the initial margin the order implies at the given leverage
+	Margin float64 `json:"margin"`
+	// Risk is the loss if the stop distance is reached; zero for fixed-notional sizing
+	Risk float64 `json:"risk"`
+	// Capped is set when the quantity was reduced to the asset's MaxQuantity
+	Capped bool `json:"capped,omitempty"`
+}
+
+// RiskSizing sizes a position so that reaching the stop loss loses
+// RiskPercent of Equity
+type RiskSizing struct {
+	Equity float64
+	// RiskPercent is the share of equity risked, e.g. 1 for 1%
+	RiskPercent float64
+	Entry       float64
+	StopLoss    float64
+	Leverage    float64
+}
+
+// ATRSizing sizes a position with a stop distance of Multiplier times the
+// average true range of Candles
+type ATRSizing struct {
+	Equity      float64
+	RiskPercent float64
+	Entry       float64
+	Leverage    float64
+	Candles     []Candle
+	// Period of the average true range (default 14)
+	Period int
+	// Multiplier of the average true range giving the stop distance (default 2)
+	Multiplier float64
+}
+
+// SizeByRisk computes the quantity whose loss at the stop-loss price is the
+// risk budget
+func SizeByRisk(asset *Asset, p RiskSizing) (*PositionSize, error) {
+	if p.StopLoss <= 0 {
+		return nil, validationError("stop loss must be positive")
+	}
+	distance := math.Abs(p.Entry - p.StopLoss)
+	if distance == 0 {
+		return nil, validationError("stop loss must differ from entry")
+	}
+	return sizeByDistance(asset, p.Equity, p.RiskPercent, p.Entry, distance, p.Leverage)
+}
+
+// SizeByNotional computes the quantity worth notional at price
+func SizeByNotional(asset *Asset, notional, price, leverage float64) (*PositionSize, error) {
+	if notional <= 0 {
+		return nil, validationError("notional must be positive")
+	}
+	if price <= 0 {
+		return nil, validationError("price must be positive")
+	}
+	return newPositionSize(asset, notional/price, price, leverage, 0)
+}
+
+// SizeByATR computes the quantity whose loss at an ATR-based stop distance is
+// the risk budget, scaling positions down as volatility rises
+func SizeByATR(asset *Asset, p ATRSizing) (*PositionSize, error) {
+	if p.Period <= 0 {
+		p.Period = 14
+	}
+	if p.Multiplier <= 0 {
+		p.Multiplier = 2
+	}
+	atr, err := averageTrueRange(p.Candles, p.Period)
+	if err != nil {
+		return nil, err
+	}
+	if atr == 0 {
+		return nil, validationError("average true range is zero")
+	}
+	return sizeByDistance(asset, p.Equity, p.RiskPercent, p.Entry, atr*p.Multiplier, p.Leverage)
+}
+
+func sizeByDistance(asset *Asset, equity, riskPercent, entry, distance, leverage float64) (*PositionSize, error) {
+	if equity <= 0 {
+		return nil, validationError("equity must be positive")
+	}
+	if riskPercent <= 0 || riskPercent > 100 {
+		return nil, validationError("risk percent must be in (0, 100]")
+	}
+	if entry <= 0 {
+		return nil, validationError("entry price must be positive")
+	}
+	quantity := equity * riskPercent / 100 / distance
+	return newPositionSize(asset, quantity, entry, leverage, distance)
+}
+
+// newPositionSize rounds quantity to the asset's step and limits and derives
+// notional, margin and risk
+func newPositionSize(asset *Asset, quantity, price, leverage, distance float64) (*PositionSize, error) {
+	if leverage <= 0 {
+		return nil, validationError("leverage must be positive")
+	}
+	step, err := ParseDecimal("quantity_step", asset.QuantityStep)
+	if err != nil {
+		return nil, err
+	}
+	minQuantity, err := ParseDecimal("min_quantity", asset.MinQuantity)
+	if err != nil {
+		return nil, err
+	}
+	maxQuantity, err := ParseDecimal("max_quantity", asset.MaxQuantity)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PositionSize{Price: price, Leverage: leverage}
+	if maxQuantity > 0 && quantity > maxQuantity {
+		quantity, s.Capped = maxQuantity, true
+	}
+	if step > 0 {
+		// The epsilon keeps exact multiples from flooring one step down
+		quantity = math.Floor(quantity/step+1e-9) * step
+	}
+	if quantity <= 0 || quantity < minQuantity {
+		return nil, validationError(fmt.Sprintf("computed quantity %v is below the minimum %s for %s", quantity, asset.MinQuantity, asset.AssetID))
+	}
+
+	s.Quantity = formatQuantity(quantity, asset.QuantityStep)
+	s.QuantityValue, _ = strconv.ParseFloat(s.Quantity, 64)
+	s.Notional = s.QuantityValue * price
+	s.Margin = s.Notional / leverage
+	s.Risk = s.QuantityValue * distance
+	return s, nil
+}
+
+// formatQuantity formats quantity with as many decimals as step
+func formatQuantity(quantity float64, step string) string {
+	decimals := -1
+	if step != "" {
+		decimals = 0
+		if i := strings.IndexByte(step, '.'); i >= 0 {
+			decimals = len(strings.TrimRight(step[i+1:], "0"))
+		}
+	}
+	return strconv.FormatFloat(quantity, 'f', decimals, 64)
+}
+
+// averageTrueRange is Wilder's average true range over the last period
+// candles, needing period+1 candles
+func averageTrueRange(candles []Candle, period int) (float64, error) {
+	if len(candles) < period+1 {
+		return 0, validationError(fmt.Sprintf("ATR over %d periods needs at least %d candles, got %d", period, period+1, len(candles)))
+	}
+
+	var atr float64
+	for i := 1; i < len(candles); i++ {
+		atr = wilder.Smooth(atr, wilder.TrueRange(candles[i].High, candles[i].Low, candles[i-1].Close), i, period)
+	}
+	return atr, nil
+}
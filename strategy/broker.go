@@ -0,0 +1,67 @@
+package strategy
+
+import (
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+	"github.com/DecentralizedJM/mudrex-go-sdk/backtest"
+)
+
+// Broker is the execution venue a strategy runs against: the live API via
+// ClientBroker, or a simulated broker such as backtest.Broker
+type Broker interface {
+	OpenPositions() ([]mudrex.Position, error)
+	OpenOrders(assetID string) ([]mudrex.Order, error)
+	CreateOrder(assetID string, req *mudrex.OrderRequest) (*mudrex.Order, error)
+	CancelOrder(assetID, orderID string) error
+	ClosePosition(positionID string) error
+}
+
+// OrderGetter is implemented by brokers that can look up a single order.
+// The runtime uses it to report the final status of orders that leave the
+// open order list.
+type OrderGetter interface {
+	GetOrder(assetID, orderID string) (*mudrex.Order, error)
+}
+
+var _ Broker = (*backtest.Broker)(nil)
+
+// ClientBroker adapts a Client to the Broker interface
+type ClientBroker struct {
+	client *mudrex.Client
+}
+
+// NewClientBroker creates a broker that trades through client
+func NewClientBroker(client *mudrex.Client) *ClientBroker {
+	return &ClientBroker{client: client}
+}
+
+// OpenPositions lists open positions
+func (b *ClientBroker) OpenPositions() ([]mudrex.Position, error) {
+	return b.client.Positions.ListOpen()
+}
+
+// OpenOrders lists open orders for an asset
+func (b *ClientBroker) OpenOrders(assetID string) ([]mudrex.Order, error) {
+	return b.client.Orders.ListOpen(assetID)
+}
+
+// CreateOrder places an order
+func (b *ClientBroker) CreateOrder(assetID string, req *mudrex.OrderRequest) (*mudrex.Order, error) {
+	return b.client.Orders.Create(assetID, req)
+}
+
+// CancelOrder cancels an open order
+func (b *ClientBroker) CancelOrder(assetID, orderID string) error {
+	_, err := b.client.Orders.Cancel(assetID, orderID)
+	return err
+}
+
+// ClosePosition closes a position completely
+func (b *ClientBroker) ClosePosition(positionID string) error {
+	_, err := b.client.Positions.Close(positionID)
+	return err
+}
+
+// GetOrder retrieves a single order
+func (b *ClientBroker) GetOrder(assetID, orderID string) (*mudrex.Order, error) {
+	return b.client.Orders.Get(assetID, orderID)
+}
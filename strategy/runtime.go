@@ -0,0 +1,395 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// Strategy receives lifecycle and market events from a Runtime. Callbacks are
+// never invoked concurrently.
+type Strategy interface {
+	OnStart(ctx *Context) error
+	OnTick(ctx *Context) error
+	OnOrderUpdate(ctx *Context, order mudrex.Order) error
+	OnPositionUpdate(ctx *Context, position mudrex.Position) error
+	OnStop(ctx *Context) error
+}
+
+// Base implements every Strategy callback as a no-op. Embed it to only
+// implement the hooks a strategy needs.
+type Base struct{}
+
+func (Base) OnStart(*Context) error                           { return nil }
+func (Base) OnTick(*Context) error                            { return nil }
+func (Base) OnOrderUpdate(*Context, mudrex.Order) error       { return nil }
+func (Base) OnPositionUpdate(*Context, mudrex.Position) error { return nil }
+func (Base) OnStop(*Context) error                            { return nil }
+
+// Context is passed to every strategy callback. It embeds the runtime's
+// context.Context, which is cancelled when shutdown begins (OnStop receives
+// a fresh context bounded by Config.ShutdownTimeout).
+type Context struct {
+	context.Context
+	// Broker executes orders
+	Broker Broker
+	// Now is when the current poll completed
+	Now time.Time
+	// Positions is the latest snapshot of open positions
+	Positions []mudrex.Position
+	// Orders is the latest snapshot of open orders keyed by asset ID
+	Orders map[string][]mudrex.Order
+}
+
+// ErrStop can be returned by a callback to shut the runtime down gracefully
+var ErrStop = errors.New("strategy: stop")
+
+// Config configures a Runtime
+type Config struct {
+	// Assets whose open orders are polled
+	Assets []string
+	// Interval between polls; OnTick runs after each poll (default 5s)
+	Interval time.Duration
+	// FlattenOnStop cancels open orders and closes open positions on shutdown
+	FlattenOnStop bool
+	// ShutdownTimeout bounds flattening and OnStop (default 30s)
+	ShutdownTimeout time.Duration
+	// OnError is called with every poll or callback error. Returning nil keeps
+	// the runtime going; returning an error stops it. When nil, poll errors are
+	// retried on the next interval and callback errors stop the runtime.
+	OnError func(err error) error
+}
+
+// PollError wraps a failure to fetch state from the broker
+type PollError struct {
+	Op  string
+	Err error
+}
+
+func (e *PollError) Error() string {
+	return fmt.Sprintf("poll %s: %v", e.Op, e.Err)
+}
+
+func (e *PollError) Unwrap() error {
+	return e.Err
+}
+
+// Runtime drives a Strategy against a Broker
+type Runtime struct {
+	broker   Broker
+	strategy Strategy
+	config   Config
+
+	mu      sync.Mutex
+	running bool
+
+	positions map[string]mudrex.Position
+	orders    map[string]mudrex.Order
+	snapshot  *Context
+}
+
+// New creates a runtime for strategy trading through broker
+func New(broker Broker, strategy Strategy, config Config) *Runtime {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = 30 * time.Second
+	}
+
+	return &Runtime{
+		broker:   broker,
+		strategy: strategy,
+		config:   config,
+	}
+}
+
+// Run starts the strategy and blocks until ctx is cancelled, a callback
+// returns ErrStop, or an error stops the runtime. Once the initial poll has
+// succeeded, shutdown always runs OnStop.
+func (r *Runtime) Run(ctx context.Context) (err error) {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return fmt.Errorf("runtime is already running")
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.running = false
+		r.mu.Unlock()
+	}()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The initial snapshot seeds state without emitting update events.
+	snapshot, err := r.poll(runCtx)
+	if err != nil {
+		return err
+	}
+	r.remember(snapshot)
+
+	defer func() {
+		cancel()
+		if stopErr := r.shutdown(); stopErr != nil && err == nil {
+			err = stopErr
+		}
+	}()
+
+	if err := r.strategy.OnStart(snapshot); err != nil {
+		return r.stopErr(err)
+	}
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-runCtx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.cycle(runCtx); err != nil {
+				return r.stopErr(err)
+			}
+		}
+	}
+}
+
+// cycle polls the broker, dispatches updates and runs OnTick
+func (r *Runtime) cycle(ctx context.Context) error {
+	snapshot, err := r.poll(ctx)
+	if err != nil {
+		return r.handle(err)
+	}
+
+	for _, order := range r.orderUpdates(snapshot) {
+		if err := r.strategy.OnOrderUpdate(snapshot, order); err != nil {
+			if err := r.handle(err); err != nil {
+				return err
+			}
+		}
+	}
+	for _, position := range r.positionUpdates(snapshot) {
+		if err := r.strategy.OnPositionUpdate(snapshot, position); err != nil {
+			if err := r.handle(err); err != nil {
+				return err
+			}
+		}
+	}
+	r.remember(snapshot)
+
+	if err := r.strategy.OnTick(snapshot); err != nil {
+		return r.handle(err)
+	}
+	return nil
+}
+
+func (r *Runtime) poll(ctx context.Context) (*Context, error) {
+	positions, err := r.broker.OpenPositions()
+	if err != nil {
+		return nil, &PollError{Op: "positions", Err: err}
+	}
+
+	orders := make(map[string][]mudrex.Order, len(r.config.Assets))
+	for _, assetID := range r.config.Assets {
+		open, err := r.broker.OpenOrders(assetID)
+		if err != nil {
+			return nil, &PollError{Op: "orders " + assetID, Err: err}
+		}
+		orders[assetID] = open
+	}
+
+	return &Context{
+		Context:   ctx,
+		Broker:    r.broker,
+		Now:       time.Now(),
+		Positions: positions,
+		Orders:    orders,
+	}, nil
+}
+
+// orderUpdates returns orders that are new, changed, or no longer open.
+// Orders that left the open list are looked up for their final status when
+// the broker supports it, and otherwise reported with their last known state.
+func (r *Runtime) orderUpdates(snapshot *Context) []mudrex.Order {
+	var updates []mudrex.Order
+	seen := make(map[string]bool)
+
+	for _, open := range snapshot.Orders {
+		for _, order := range open {
+			seen[order.OrderID] = true
+			prev, ok := r.orders[order.OrderID]
+			if !ok || orderChanged(prev, order) {
+				updates = append(updates, order)
+			}
+		}
+	}
+
+	getter, canGet := r.broker.(OrderGetter)
+	for id, prev := range r.orders {
+		if seen[id] {
+			continue
+		}
+		final := prev
+		if canGet {
+			if order, err := getter.GetOrder(prev.AssetID, id); err == nil {
+				final = *order
+			}
+		}
+		updates = append(updates, final)
+	}
+
+	return updates
+}
+
+// positionUpdates returns positions that are new, changed, or closed.
+// Closed positions are reported with Status set to CLOSED.
+func (r *Runtime) positionUpdates(snapshot *Context) []mudrex.Position {
+	var updates []mudrex.Position
+	seen := make(map[string]bool)
+
+	for _, position := range snapshot.Positions {
+		seen[position.PositionID] = true
+		prev, ok := r.positions[position.PositionID]
+		if !ok || positionChanged(prev, position) {
+			updates = append(updates, position)
+		}
+	}
+
+	for id, prev := range r.positions {
+		if !seen[id] {
+			prev.Status = mudrex.PositionStatusClosed
+			updates = append(updates, prev)
+		}
+	}
+
+	return updates
+}
+
+func (r *Runtime) remember(snapshot *Context) {
+	r.positions = make(map[string]mudrex.Position, len(snapshot.Positions))
+	for _, position := range snapshot.Positions {
+		r.positions[position.PositionID] = position
+	}
+
+	r.orders = make(map[string]mudrex.Order)
+	for _, open := range snapshot.Orders {
+		for _, order := range open {
+			r.orders[order.OrderID] = order
+		}
+	}
+
+	r.snapshot = snapshot
+}
+
+// shutdown flattens if configured and calls OnStop
+func (r *Runtime) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.ShutdownTimeout)
+	defer cancel()
+
+	stopCtx := &Context{Context: ctx, Broker: r.broker, Now: time.Now()}
+	if r.snapshot != nil {
+		stopCtx.Positions = r.snapshot.Positions
+		stopCtx.Orders = r.snapshot.Orders
+	}
+
+	var errs []error
+	if r.config.FlattenOnStop {
+		errs = append(errs, r.flatten(ctx))
+	}
+	if err := r.strategy.OnStop(stopCtx); err != nil && !errors.Is(err, ErrStop) {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// flatten cancels open orders and closes open positions
+func (r *Runtime) flatten(ctx context.Context) error {
+	var errs []error
+
+	for _, assetID := range r.config.Assets {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		orders, err := r.broker.OpenOrders(assetID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("list orders %s: %w", assetID, err))
+			continue
+		}
+		for _, order := range orders {
+			if err := r.broker.CancelOrder(assetID, order.OrderID); err != nil {
+				errs = append(errs, fmt.Errorf("cancel order %s: %w", order.OrderID, err))
+			}
+		}
+	}
+
+	positions, err := r.broker.OpenPositions()
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("list positions: %w", err))...)
+	}
+	for _, position := range positions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := r.broker.ClosePosition(position.PositionID); err != nil {
+			errs = append(errs, fmt.Errorf("close position %s: %w", position.PositionID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// handle routes an error through Config.OnError, returning non-nil to stop
+func (r *Runtime) handle(err error) error {
+	if errors.Is(err, ErrStop) {
+		return err
+	}
+	if r.config.OnError != nil {
+		return r.config.OnError(err)
+	}
+
+	var pollErr *PollError
+	if errors.As(err, &pollErr) {
+		return nil
+	}
+	return err
+}
+
+// stopErr converts ErrStop into a clean exit
+func (r *Runtime) stopErr(err error) error {
+	if errors.Is(err, ErrStop) {
+		return nil
+	}
+	return err
+}
+
+func orderChanged(a, b mudrex.Order) bool {
+	return a.Status != b.Status ||
+		a.FilledQuantity != b.FilledQuantity ||
+		a.Quantity != b.Quantity ||
+		a.Price != b.Price
+}
+
+func positionChanged(a, b mudrex.Position) bool {
+	return a.Status != b.Status ||
+		a.Quantity != b.Quantity ||
+		a.EntryPrice != b.EntryPrice ||
+		a.Leverage != b.Leverage ||
+		!equalPtr(a.StopLoss, b.StopLoss) ||
+		!equalPtr(a.TakeProfit, b.TakeProfit)
+}
+
+func equalPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
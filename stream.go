@@ -0,0 +1,666 @@
+package mudrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DecentralizedJM/mudrex-go-sdk/internal/wsconn"
+)
+
+// StreamChannel identifies a kind of stream update
+type StreamChannel string
+
+const (
+	StreamOrders    StreamChannel = "orders"
+	StreamPositions StreamChannel = "positions"
+	StreamBalance   StreamChannel = "balance"
+)
+
+// StreamSource identifies the transport that produced an update
+type StreamSource string
+
+const (
+	SourceNone      StreamSource = ""
+	SourceWebSocket StreamSource = "websocket"
+	SourcePolling   StreamSource = "polling"
+)
+
+// StreamUpdate is delivered to subscribers. Exactly one of Order, Position
+// and Balance is set, according to Channel.
+type StreamUpdate struct {
+	Channel  StreamChannel
+	Source   StreamSource
+	Time     time.Time
+	Order    *Order
+	Position *Position
+	Balance  *FuturesBalance
+}
+
+// StreamMessage is the JSON frame exchanged over the WebSocket transport.
+// Clients send {"op":"subscribe","channel":...,"assets":[...]}; the server
+// sends {"channel":...,"data":{...}} with an Order, Position or FuturesBalance.
+type StreamMessage struct {
+	Op      string          `json:"op,omitempty"`
+	Channel StreamChannel   `json:"channel,omitempty"`
+	Assets  []string        `json:"assets,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Stream delivers order, position and balance updates over whichever
+// transport is currently available
+type Stream interface {
+	// SubscribeOrders subscribes to order updates for the given assets.
+	// Polling can only observe orders for assets listed here.
+	SubscribeOrders(assetIDs ...string) *Subscription
+	// SubscribePositions subscribes to position updates, optionally
+	// filtered to the given assets
+	SubscribePositions(assetIDs ...string) *Subscription
+	// SubscribeBalance subscribes to futures balance changes
+	SubscribeBalance() *Subscription
+	// Run drives the transports until ctx is cancelled, then closes every
+	// subscription
+	Run(ctx context.Context) error
+	// Source reports the transport currently in use
+	Source() StreamSource
+}
+
+// StreamConfig configures a Stream
+type StreamConfig struct {
+	// URL of the WebSocket endpoint. When empty the stream only polls.
+	URL string
+	// PollInterval between REST polls in fallback mode (default 5s)
+	PollInterval time.Duration
+	// HeartbeatInterval between client pings (default 15s)
+	HeartbeatInterval time.Duration
+	// HeartbeatTimeout without any frame before reconnecting (default 3x HeartbeatInterval)
+	HeartbeatTimeout time.Duration
+	// MinBackoff and MaxBackoff bound reconnect delays (default 1s and 30s)
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// FallbackAfter consecutive failed connections switches to polling (default 3)
+	FallbackAfter int
+	// RetryWebSocketAfter is how long to poll before trying WebSocket again (default 1m)
+	RetryWebSocketAfter time.Duration
+	// BufferSize of each subscription channel (default 64)
+	BufferSize int
+	// OnError receives connection and poll errors; Run keeps going
+	OnError func(error)
+}
+
+// Subscription receives updates on C until it is closed, either by Close or
+// by the stream's Run returning; C is then closed too
+type Subscription struct {
+	C <-chan StreamUpdate
+
+	c       chan StreamUpdate
+	channel StreamChannel
+	assets  map[string]bool
+	stream  *stream
+	done    chan struct{}
+	once    sync.Once
+
+	// mu is held for reading while sending on c, so c is never closed
+	// under a send
+	mu     sync.RWMutex
+	closed bool
+}
+
+// Close stops delivery to the subscription and closes C
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		// Closing done first unblocks a pending send before taking mu.
+		close(s.done)
+		s.mu.Lock()
+		s.closed = true
+		close(s.c)
+		s.mu.Unlock()
+		s.stream.unsubscribe(s)
+	})
+}
+
+// send delivers u unless the subscription or ctx is done. It reports false
+// only when ctx is done.
+func (s *Subscription) send(ctx context.Context, u StreamUpdate) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return true
+	}
+	select {
+	case s.c <- u:
+	case <-s.done:
+	case <-ctx.Done():
+		return false
+	}
+	return true
+}
+
+func (s *Subscription) wants(u StreamUpdate) bool {
+	if u.Channel != s.channel {
+		return false
+	}
+	if len(s.assets) == 0 {
+		return true
+	}
+	switch {
+	case u.Order != nil:
+		return s.assets[u.Order.AssetID] || s.assets[u.Order.Symbol]
+	case u.Position != nil:
+		return s.assets[u.Position.AssetID] || s.assets[u.Position.Symbol]
+	}
+	return true
+}
+
+type stream struct {
+	client *Client
+	config StreamConfig
+
+	mu     sync.Mutex
+	subs   map[*Subscription]bool
+	source StreamSource
+	conn   *wsconn.Conn
+	// changed is signalled when the subscription set changes while polling
+	changed chan struct{}
+}
+
+// NewStream creates a stream for client
+func NewStream(client *Client, config StreamConfig) Stream {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 15 * time.Second
+	}
+	if config.HeartbeatTimeout <= 0 {
+		config.HeartbeatTimeout = 3 * config.HeartbeatInterval
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 30 * time.Second
+	}
+	if config.FallbackAfter <= 0 {
+		config.FallbackAfter = 3
+	}
+	if config.RetryWebSocketAfter <= 0 {
+		config.RetryWebSocketAfter = time.Minute
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 64
+	}
+
+	return &stream{
+		client:  client,
+		config:  config,
+		subs:    make(map[*Subscription]bool),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+func (s *stream) SubscribeOrders(assetIDs ...string) *Subscription {
+	return s.subscribe(StreamOrders, assetIDs)
+}
+
+func (s *stream) SubscribePositions(assetIDs ...string) *Subscription {
+	return s.subscribe(StreamPositions, assetIDs)
+}
+
+func (s *stream) SubscribeBalance() *Subscription {
+	return s.subscribe(StreamBalance, nil)
+}
+
+func (s *stream) subscribe(channel StreamChannel, assetIDs []string) *Subscription {
+	c := make(chan StreamUpdate, s.config.BufferSize)
+	sub := &Subscription{
+		C:       c,
+		c:       c,
+		channel: channel,
+		assets:  make(map[string]bool, len(assetIDs)),
+		stream:  s,
+		done:    make(chan struct{}),
+	}
+	for _, assetID := range assetIDs {
+		sub.assets[assetID] = true
+	}
+
+	s.mu.Lock()
+	s.subs[sub] = true
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn != nil {
+		// A failed send surfaces as a read error and triggers resubscription.
+		s.sendSubscribe(conn, sub)
+	}
+	s.notifyChanged()
+
+	return sub
+}
+
+func (s *stream) unsubscribe(sub *Subscription) {
+	s.mu.Lock()
+	delete(s.subs, sub)
+	s.mu.Unlock()
+	s.notifyChanged()
+}
+
+func (s *stream) notifyChanged() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (s *stream) Source() StreamSource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.source
+}
+
+func (s *stream) setSource(source StreamSource, conn *wsconn.Conn) {
+	s.mu.Lock()
+	s.source = source
+	s.conn = conn
+	s.mu.Unlock()
+}
+
+func (s *stream) subscriptions() []*Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := make([]*Subscription, 0, len(s.subs))
+	for sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// dispatch delivers an update to every matching subscription
+func (s *stream) dispatch(ctx context.Context, u StreamUpdate) {
+	for _, sub := range s.subscriptions() {
+		if !sub.wants(u) {
+			continue
+		}
+		if !sub.send(ctx, u) {
+			return
+		}
+	}
+}
+
+func (s *stream) Run(ctx context.Context) error {
+	defer s.setSource(SourceNone, nil)
+	defer func() {
+		for _, sub := range s.subscriptions() {
+			sub.Close()
+		}
+	}()
+
+	failures := 0
+	backoff := s.config.MinBackoff
+
+	for ctx.Err() == nil {
+		if s.config.URL != "" && failures < s.config.FallbackAfter {
+			connected, err := s.runWebSocket(ctx)
+			if ctx.Err() != nil {
+				break
+			}
+			s.report(err)
+			if connected {
+				failures = 0
+				backoff = s.config.MinBackoff
+			} else {
+				failures++
+			}
+
+			if !sleepContext(ctx, backoff) {
+				break
+			}
+			if backoff *= 2; backoff > s.config.MaxBackoff {
+				backoff = s.config.MaxBackoff
+			}
+			continue
+		}
+
+		pollCtx, cancel := ctx, context.CancelFunc(func() {})
+		if s.config.URL != "" {
+			pollCtx, cancel = context.WithTimeout(ctx, s.config.RetryWebSocketAfter)
+		}
+		s.runPolling(pollCtx)
+		cancel()
+
+		failures = 0
+		backoff = s.config.MinBackoff
+	}
+
+	return nil
+}
+
+// runWebSocket connects, resubscribes and reads until the connection fails.
+// connected reports whether the handshake succeeded.
+func (s *stream) runWebSocket(ctx context.Context) (connected bool, err error) {
+	dialTimeout := s.client.timeout
+	if dialTimeout <= 0 {
+		dialTimeout = 30 * time.Second
+	}
+	secret, err := s.client.secrets.Secret()
+	if err != nil {
+		return false, err
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	header := http.Header{}
+	header.Set("X-Authentication", secret)
+	conn, err := wsconn.Dial(dialCtx, s.config.URL, header)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	s.setSource(SourceWebSocket, conn)
+	defer s.setSource(SourceNone, nil)
+
+	for _, sub := range s.subscriptions() {
+		if err := s.sendSubscribe(conn, sub); err != nil {
+			return true, err
+		}
+	}
+
+	timeout := s.config.HeartbeatTimeout
+	conn.OnPong = func([]byte) {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+	}
+
+	heartbeatDone := make(chan struct{})
+	defer close(heartbeatDone)
+	go func() {
+		ticker := time.NewTicker(s.config.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if conn.WriteMessage(wsconn.OpPing, nil) != nil {
+					return
+				}
+			case <-heartbeatDone:
+				return
+			case <-ctx.Done():
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+
+		var msg StreamMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Channel == "" || len(msg.Data) == 0 {
+			continue
+		}
+		if u, ok := decodeStreamUpdate(msg); ok {
+			s.dispatch(ctx, u)
+		}
+	}
+}
+
+func (s *stream) sendSubscribe(conn *wsconn.Conn, sub *Subscription) error {
+	msg := StreamMessage{Op: "subscribe", Channel: sub.channel}
+	for assetID := range sub.assets {
+		msg.Assets = append(msg.Assets, assetID)
+	}
+	sort.Strings(msg.Assets)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(wsconn.OpText, data)
+}
+
+func decodeStreamUpdate(msg StreamMessage) (StreamUpdate, bool) {
+	u := StreamUpdate{Channel: msg.Channel, Source: SourceWebSocket, Time: time.Now()}
+
+	switch msg.Channel {
+	case StreamOrders:
+		u.Order = new(Order)
+		return u, json.Unmarshal(msg.Data, u.Order) == nil
+	case StreamPositions:
+		u.Position = new(Position)
+		return u, json.Unmarshal(msg.Data, u.Position) == nil
+	case StreamBalance:
+		u.Balance = new(FuturesBalance)
+		return u, json.Unmarshal(msg.Data, u.Balance) == nil
+	}
+	return u, false
+}
+
+// runPolling emulates the stream over REST until ctx is done
+func (s *stream) runPolling(ctx context.Context) {
+	s.setSource(SourcePolling, nil)
+	defer s.setSource(SourceNone, nil)
+
+	var (
+		positions map[string]Position
+		orders    map[string]Order
+		balance   *FuturesBalance
+	)
+	schedule := newPollSchedule(s.config.PollInterval, s.config.MaxBackoff)
+
+	for {
+		channels, assets := s.pollTargets()
+
+		var errs []error
+		if channels[StreamPositions] {
+			next, err := s.pollPositions(ctx, positions)
+			if err == nil {
+				positions = next
+			}
+			errs = append(errs, err)
+		} else {
+			positions = nil
+		}
+		if channels[StreamOrders] {
+			next, err := s.pollOrders(ctx, assets, orders)
+			if err == nil {
+				orders = next
+			}
+			errs = append(errs, err)
+		} else {
+			orders = nil
+		}
+		if channels[StreamBalance] {
+			next, err := s.pollBalance(ctx, balance)
+			if err == nil {
+				balance = next
+			}
+			errs = append(errs, err)
+		} else {
+			balance = nil
+		}
+		err := errors.Join(errs...)
+		if ctx.Err() == nil {
+			s.report(err)
+		}
+		schedule.done(err)
+
+		if !s.waitPoll(ctx, schedule) {
+			return
+		}
+	}
+}
+
+// waitPoll waits until the next poll is due, reporting false once ctx is
+// done. A subscription change polls early, except while backing off from
+// rate limits, so a new subscription cannot cut the backoff short.
+func (s *stream) waitPoll(ctx context.Context, schedule *pollSchedule) bool {
+	timer := time.NewTimer(time.Until(schedule.next))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-s.changed:
+			if schedule.backoff == 0 {
+				return true
+			}
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+func (s *stream) pollTargets() (map[StreamChannel]bool, []string) {
+	channels := make(map[StreamChannel]bool)
+	assetSet := make(map[string]bool)
+	for _, sub := range s.subscriptions() {
+		channels[sub.channel] = true
+		if sub.channel == StreamOrders {
+			for assetID := range sub.assets {
+				assetSet[assetID] = true
+			}
+		}
+	}
+
+	assets := make([]string, 0, len(assetSet))
+	for assetID := range assetSet {
+		assets = append(assets, assetID)
+	}
+	sort.Strings(assets)
+	return channels, assets
+}
+
+func (s *stream) pollPositions(ctx context.Context, prev map[string]Position) (map[string]Position, error) {
+	open, err := s.client.Positions.ListOpen()
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]Position, len(open))
+	for _, p := range open {
+		current[p.PositionID] = p
+	}
+	if prev == nil {
+		return current, nil
+	}
+
+	now := time.Now()
+	for id, p := range current {
+		if old, ok := prev[id]; !ok || old.Quantity != p.Quantity || old.Status != p.Status {
+			p := p
+			s.dispatch(ctx, StreamUpdate{Channel: StreamPositions, Source: SourcePolling, Time: now, Position: &p})
+		}
+	}
+	for id, old := range prev {
+		if _, ok := current[id]; ok {
+			continue
+		}
+		// Until Get reports a final status the position may have been
+		// closed or liquidated, so keep tracking it
+		final, err := s.client.Positions.Get(id)
+		if err != nil {
+			s.report(fmt.Errorf("position %s is no longer open, final status unknown: %w", id, err))
+			current[id] = old
+			continue
+		}
+		if final.Status != PositionStatusClosed && final.Status != PositionStatusLiquidated {
+			current[id] = old
+			continue
+		}
+		s.dispatch(ctx, StreamUpdate{Channel: StreamPositions, Source: SourcePolling, Time: now, Position: final})
+	}
+
+	return current, nil
+}
+
+func (s *stream) pollOrders(ctx context.Context, assets []string, prev map[string]Order) (map[string]Order, error) {
+	current := make(map[string]Order)
+	for _, assetID := range assets {
+		open, err := s.client.Orders.ListOpen(assetID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", assetID, err)
+		}
+		for _, o := range open {
+			if o.AssetID == "" {
+				o.AssetID = assetID
+			}
+			current[o.OrderID] = o
+		}
+	}
+	if prev == nil {
+		return current, nil
+	}
+
+	now := time.Now()
+	for id, o := range current {
+		if old, ok := prev[id]; !ok || old.Status != o.Status || old.FilledQuantity != o.FilledQuantity {
+			o := o
+			s.dispatch(ctx, StreamUpdate{Channel: StreamOrders, Source: SourcePolling, Time: now, Order: &o})
+		}
+	}
+	for id, old := range prev {
+		if _, ok := current[id]; ok {
+			continue
+		}
+		// Until Get reports a final status the order may have filled or
+		// been cancelled, so keep tracking it
+		final, err := s.client.Orders.Get(old.AssetID, id)
+		if err != nil {
+			s.report(fmt.Errorf("order %s is no longer open, final status unknown: %w", id, err))
+			current[id] = old
+			continue
+		}
+		switch final.Status {
+		case OrderStatusFilled, OrderStatusCancelled, OrderStatusExpired:
+			s.dispatch(ctx, StreamUpdate{Channel: StreamOrders, Source: SourcePolling, Time: now, Order: final})
+		default:
+			current[id] = old
+		}
+	}
+
+	return current, nil
+}
+
+func (s *stream) pollBalance(ctx context.Context, prev *FuturesBalance) (*FuturesBalance, error) {
+	balance, err := s.client.Wallet.GetFuturesBalance()
+	if err != nil {
+		return nil, err
+	}
+
+	if prev != nil && (prev.Balance != balance.Balance || prev.LockedAmount != balance.LockedAmount) {
+		update := *balance
+		s.dispatch(ctx, StreamUpdate{Channel: StreamBalance, Source: SourcePolling, Time: time.Now(), Balance: &update})
+	}
+	return balance, nil
+}
+
+func (s *stream) report(err error) {
+	if err != nil && s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
+
+// sleepContext waits for d, returning false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,235 @@
+package mudrex_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+	"github.com/DecentralizedJM/mudrex-go-sdk/mudrextest"
+)
+
+// fundsServer serves /futures/funds for polling, answering 429 while
+// rateLimited is set
+type fundsServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	balance     string
+	rateLimited bool
+	requests    int
+}
+
+func newFundsServer(balance string) *fundsServer {
+	s := &fundsServer{balance: balance}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path != "/futures/funds":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": "not found"})
+		case s.rateLimited:
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "message": "slow down"})
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": true,
+				"data":    mudrex.FuturesBalance{Balance: s.balance, LockedAmount: "0"},
+			})
+		}
+	}))
+	return s
+}
+
+func (s *fundsServer) setBalance(balance string) {
+	s.mu.Lock()
+	s.balance = balance
+	s.mu.Unlock()
+}
+
+func (s *fundsServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// newTestClient returns a client for baseURL whose rate limiter does not
+// slow polling down
+func newTestClient(baseURL string) *mudrex.Client {
+	client := mudrex.NewClientWithConfig("secret", baseURL, 5*time.Second)
+	client.SetRateLimit(1000)
+	return client
+}
+
+// errorLog collects errors passed to StreamConfig.OnError
+type errorLog struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (l *errorLog) add(err error) {
+	l.mu.Lock()
+	l.errs = append(l.errs, err)
+	l.mu.Unlock()
+}
+
+func (l *errorLog) all() []error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]error(nil), l.errs...)
+}
+
+// runStream runs stream until the test ends
+func runStream(t *testing.T, stream mudrex.Stream) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		stream.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func receive(t *testing.T, sub *mudrex.Subscription) mudrex.StreamUpdate {
+	t.Helper()
+	select {
+	case u := <-sub.C:
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an update")
+	}
+	return mudrex.StreamUpdate{}
+}
+
+func TestStreamFallsBackToPolling(t *testing.T) {
+	ws := mudrextest.NewStreamServer()
+	defer ws.Close()
+	ws.SetRejectConnections(true)
+	rest := newFundsServer("100")
+	defer rest.Close()
+
+	var errs errorLog
+	stream := mudrex.NewStream(newTestClient(rest.URL), mudrex.StreamConfig{
+		URL:           ws.WebSocketURL(),
+		PollInterval:  10 * time.Millisecond,
+		MinBackoff:    time.Millisecond,
+		FallbackAfter: 2,
+		OnError:       errs.add,
+	})
+	sub := stream.SubscribeBalance()
+	runStream(t, stream)
+
+	waitFor(t, "the polling fallback", func() bool { return stream.Source() == mudrex.SourcePolling })
+	if n := len(errs.all()); n < 2 {
+		t.Errorf("OnError got %d errors before falling back, want one per failed connection", n)
+	}
+
+	// The first poll only records the balance, so wait for it before
+	// changing it
+	waitFor(t, "the first poll", func() bool { return rest.requestCount() > 0 })
+	rest.setBalance("150")
+	u := receive(t, sub)
+	if u.Source != mudrex.SourcePolling || u.Balance == nil || u.Balance.Balance != "150" {
+		t.Errorf("update = %+v, want a polled balance of 150", u)
+	}
+}
+
+func TestStreamResubscribesAfterReconnect(t *testing.T) {
+	ws := mudrextest.NewStreamServer()
+	defer ws.Close()
+
+	var errs errorLog
+	stream := mudrex.NewStream(newTestClient(ws.URL), mudrex.StreamConfig{
+		URL:        ws.WebSocketURL(),
+		MinBackoff: time.Millisecond,
+		OnError:    errs.add,
+	})
+	sub := stream.SubscribeOrders("BTCUSDT")
+	runStream(t, stream)
+
+	subscribed := func() mudrex.StreamMessage {
+		t.Helper()
+		select {
+		case msg := <-ws.Subscribed():
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a subscribe message")
+		}
+		return mudrex.StreamMessage{}
+	}
+
+	if msg := subscribed(); msg.Channel != mudrex.StreamOrders || len(msg.Assets) != 1 || msg.Assets[0] != "BTCUSDT" {
+		t.Fatalf("subscribe message = %+v, want orders for BTCUSDT", msg)
+	}
+	ws.DropConnections()
+	if msg := subscribed(); msg.Channel != mudrex.StreamOrders {
+		t.Fatalf("resubscribe message = %+v, want orders", msg)
+	}
+	if len(errs.all()) == 0 {
+		t.Error("the dropped connection was not reported to OnError")
+	}
+
+	waitFor(t, "the reconnect", func() bool { return ws.Connections() == 1 })
+	if err := ws.Publish(mudrex.StreamOrders, mudrex.Order{OrderID: "1", AssetID: "BTCUSDT"}); err != nil {
+		t.Fatal(err)
+	}
+	u := receive(t, sub)
+	if u.Source != mudrex.SourceWebSocket || u.Order == nil || u.Order.OrderID != "1" {
+		t.Errorf("update = %+v, want order 1 over WebSocket", u)
+	}
+}
+
+func TestStreamPollBackoff(t *testing.T) {
+	rest := newFundsServer("100")
+	defer rest.Close()
+	rest.rateLimited = true
+
+	var errs errorLog
+	stream := mudrex.NewStream(newTestClient(rest.URL), mudrex.StreamConfig{
+		PollInterval: 20 * time.Millisecond,
+		MaxBackoff:   time.Second,
+		OnError:      errs.add,
+	})
+	stream.SubscribeBalance()
+	runStream(t, stream)
+
+	// Backing off doubles the wait from 40ms, so about four polls fit in
+	// 400ms where twenty would without it. New subscriptions must not cut
+	// the backoff short.
+	for i := 0; i < 8; i++ {
+		time.Sleep(50 * time.Millisecond)
+		stream.SubscribeBalance()
+	}
+	if n := rest.requestCount(); n < 2 || n > 6 {
+		t.Errorf("got %d polls in 400ms while rate limited, want 2 to 6", n)
+	}
+
+	var rateLimited *mudrex.RateLimitError
+	if all := errs.all(); len(all) == 0 || !errors.As(all[0], &rateLimited) {
+		t.Errorf("OnError got %v, want a RateLimitError", all)
+	}
+}
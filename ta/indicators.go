@@ -0,0 +1,353 @@
+package ta
+
+import (
+	"math"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+	"github.com/DecentralizedJM/mudrex-go-sdk/internal/wilder"
+)
+
+// Indicator is an incremental indicator over a series of values, usually
+// closing prices. Update returns the value after v; it is only meaningful
+// once Ready reports true.
+type Indicator interface {
+	Update(v float64) float64
+	Ready() bool
+}
+
+// CandleIndicator is an incremental indicator over candles
+type CandleIndicator interface {
+	UpdateCandle(c mudrex.Candle) float64
+	Ready() bool
+}
+
+// Apply feeds values through ind and returns its output for each, with NaN
+// while it warms up
+func Apply(ind Indicator, values []float64) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = ind.Update(v)
+		if !ind.Ready() {
+			out[i] = math.NaN()
+		}
+	}
+	return out
+}
+
+// ApplyCandles feeds candles through ind and returns its output for each,
+// with NaN while it warms up
+func ApplyCandles(ind CandleIndicator, candles []mudrex.Candle) []float64 {
+	out := make([]float64, len(candles))
+	for i, c := range candles {
+		out[i] = ind.UpdateCandle(c)
+		if !ind.Ready() {
+			out[i] = math.NaN()
+		}
+	}
+	return out
+}
+
+// Closes returns the closing prices of candles
+func Closes(candles []mudrex.Candle) []float64 {
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+	return closes
+}
+
+// window is a fixed-size ring of the latest values
+type window struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+func newWindow(size int) *window {
+	return &window{values: make([]float64, size)}
+}
+
+// push adds v and returns the value it evicted, if any
+func (w *window) push(v float64) (float64, bool) {
+	evicted, full := w.values[w.next], w.full
+	w.values[w.next] = v
+	w.next++
+	if w.next == len(w.values) {
+		w.next, w.full = 0, true
+	}
+	return evicted, full
+}
+
+// SMA is the simple moving average of the last period values
+type SMA struct {
+	window *window
+	sum    float64
+	value  float64
+}
+
+// NewSMA creates a simple moving average. It panics if period is not positive.
+func NewSMA(period int) *SMA {
+	return &SMA{window: newWindow(checkPeriod(period))}
+}
+
+func (s *SMA) Update(v float64) float64 {
+	s.sum += v
+	if evicted, ok := s.window.push(v); ok {
+		s.sum -= evicted
+	}
+	s.value = s.sum / float64(s.count())
+	return s.value
+}
+
+func (s *SMA) count() int {
+	if s.window.full {
+		return len(s.window.values)
+	}
+	return s.window.next
+}
+
+func (s *SMA) Value() float64 { return s.value }
+func (s *SMA) Ready() bool    { return s.window.full }
+
+// EMA is the exponential moving average with smoothing 2/(period+1), seeded
+// with the simple average of the first period values
+type EMA struct {
+	period int
+	alpha  float64
+	seed   float64
+	count  int
+	value  float64
+}
+
+// NewEMA creates an exponential moving average. It panics if period is not
+// positive.
+func NewEMA(period int) *EMA {
+	checkPeriod(period)
+	return &EMA{period: period, alpha: 2 / float64(period+1)}
+}
+
+func (e *EMA) Update(v float64) float64 {
+	e.count++
+	if e.count <= e.period {
+		e.seed += v
+		e.value = e.seed / float64(e.count)
+		return e.value
+	}
+	e.value += e.alpha * (v - e.value)
+	return e.value
+}
+
+func (e *EMA) Value() float64 { return e.value }
+func (e *EMA) Ready() bool    { return e.count >= e.period }
+
+// RSI is Wilder's relative strength index, between 0 and 100
+type RSI struct {
+	period  int
+	count   int
+	prev    float64
+	avgGain float64
+	avgLoss float64
+	value   float64
+}
+
+// NewRSI creates a relative strength index, ready after period+1 values. It
+// panics if period is not positive.
+func NewRSI(period int) *RSI {
+	return &RSI{period: checkPeriod(period)}
+}
+
+func (r *RSI) Update(v float64) float64 {
+	r.count++
+	if r.count == 1 {
+		r.prev = v
+		return r.value
+	}
+
+	change := v - r.prev
+	r.prev = v
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+	r.avgGain = wilder.Smooth(r.avgGain, gain, r.count-1, r.period)
+	r.avgLoss = wilder.Smooth(r.avgLoss, loss, r.count-1, r.period)
+
+	switch {
+	case r.avgLoss == 0 && r.avgGain == 0:
+		r.value = 50
+	case r.avgLoss == 0:
+		r.value = 100
+	default:
+		r.value = 100 - 100/(1+r.avgGain/r.avgLoss)
+	}
+	return r.value
+}
+
+func (r *RSI) Value() float64 { return r.value }
+func (r *RSI) Ready() bool    { return r.count > r.period }
+
+// MACDValue is one output of MACD
+type MACDValue struct {
+	MACD      float64
+	Signal    float64
+	Histogram float64
+}
+
+// MACD is the moving average convergence divergence: the difference of a
+// fast and a slow EMA, with an EMA of that difference as the signal line
+type MACD struct {
+	fast   *EMA
+	slow   *EMA
+	signal *EMA
+	value  MACDValue
+}
+
+// NewMACD creates a MACD, conventionally NewMACD(12, 26, 9)
+func NewMACD(fast, slow, signal int) *MACD {
+	return &MACD{fast: NewEMA(fast), slow: NewEMA(slow), signal: NewEMA(signal)}
+}
+
+// Update returns the MACD line; Value returns all three lines
+func (m *MACD) Update(v float64) float64 {
+	m.fast.Update(v)
+	m.slow.Update(v)
+	if !m.fast.Ready() || !m.slow.Ready() {
+		return 0
+	}
+
+	macd := m.fast.Value() - m.slow.Value()
+	signal := m.signal.Update(macd)
+	m.value = MACDValue{MACD: macd, Signal: signal, Histogram: macd - signal}
+	return macd
+}
+
+func (m *MACD) Value() MACDValue { return m.value }
+func (m *MACD) Ready() bool      { return m.signal.Ready() }
+
+// Band is one output of Bollinger
+type Band struct {
+	Upper  float64
+	Middle float64
+	Lower  float64
+}
+
+// Width returns the band width relative to the middle line
+func (b Band) Width() float64 {
+	if b.Middle == 0 {
+		return 0
+	}
+	return (b.Upper - b.Lower) / b.Middle
+}
+
+// Bollinger bands lie k population standard deviations above and below the
+// simple moving average of the last period values
+type Bollinger struct {
+	k      float64
+	window *window
+	sum    float64
+	sumSq  float64
+	value  Band
+}
+
+// NewBollinger creates Bollinger bands, conventionally NewBollinger(20, 2)
+func NewBollinger(period int, k float64) *Bollinger {
+	return &Bollinger{k: k, window: newWindow(checkPeriod(period))}
+}
+
+// Update returns the middle line; Value returns all three lines
+func (b *Bollinger) Update(v float64) float64 {
+	b.sum += v
+	b.sumSq += v * v
+	if evicted, ok := b.window.push(v); ok {
+		b.sum -= evicted
+		b.sumSq -= evicted * evicted
+	}
+
+	n := float64(b.window.next)
+	if b.window.full {
+		n = float64(len(b.window.values))
+	}
+	mean := b.sum / n
+	// Rounding can push the variance of a flat series slightly below zero
+	std := math.Sqrt(math.Max(b.sumSq/n-mean*mean, 0))
+	b.value = Band{Upper: mean + b.k*std, Middle: mean, Lower: mean - b.k*std}
+	return mean
+}
+
+func (b *Bollinger) Value() Band { return b.value }
+func (b *Bollinger) Ready() bool { return b.window.full }
+
+// ATR is Wilder's average true range, the incremental form of the one
+// SizeByATR uses. It needs period+1 candles, since the
+// first only supplies the previous close.
+type ATR struct {
+	period    int
+	count     int
+	prevClose float64
+	value     float64
+}
+
+// NewATR creates an average true range. It panics if period is not positive.
+func NewATR(period int) *ATR {
+	return &ATR{period: checkPeriod(period)}
+}
+
+func (a *ATR) UpdateCandle(c mudrex.Candle) float64 {
+	a.count++
+	prev := a.prevClose
+	a.prevClose = c.Close
+	if a.count == 1 {
+		return a.value
+	}
+
+	a.value = wilder.Smooth(a.value, wilder.TrueRange(c.High, c.Low, prev), a.count-1, a.period)
+	return a.value
+}
+
+func (a *ATR) Value() float64 { return a.value }
+func (a *ATR) Ready() bool    { return a.count > a.period }
+
+// VWAP is the volume-weighted average price, optionally reset at the start
+// of every session
+type VWAP struct {
+	session time.Duration
+	start   time.Time
+	pv      float64
+	volume  float64
+	value   float64
+}
+
+// NewVWAP creates a VWAP that resets every session, aligned to the Unix
+// epoch (24*time.Hour resets at midnight UTC). A zero session never resets.
+func NewVWAP(session time.Duration) *VWAP {
+	return &VWAP{session: session}
+}
+
+// UpdateCandle adds c weighted at its typical price, (high+low+close)/3
+func (v *VWAP) UpdateCandle(c mudrex.Candle) float64 {
+	return v.UpdateAt(c.Time, (c.High+c.Low+c.Close)/3, c.Volume)
+}
+
+// UpdateAt adds a trade of volume at price made at t
+func (v *VWAP) UpdateAt(t time.Time, price, volume float64) float64 {
+	if v.session > 0 {
+		if start := t.Truncate(v.session); !start.Equal(v.start) {
+			v.start, v.pv, v.volume = start, 0, 0
+		}
+	}
+	v.pv += price * volume
+	v.volume += volume
+	if v.volume > 0 {
+		v.value = v.pv / v.volume
+	}
+	return v.value
+}
+
+func (v *VWAP) Value() float64 { return v.value }
+func (v *VWAP) Ready() bool    { return v.volume > 0 }
+
+func checkPeriod(period int) int {
+	if period <= 0 {
+		panic("ta: period must be positive")
+	}
+	return period
+}
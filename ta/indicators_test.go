@@ -0,0 +1,181 @@
+package ta
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+func assertSeries(t *testing.T, got, want []float64, tolerance float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if math.IsNaN(want[i]) {
+			if !math.IsNaN(got[i]) {
+				t.Errorf("value %d = %v, want NaN", i, got[i])
+			}
+			continue
+		}
+		if math.Abs(got[i]-want[i]) > tolerance {
+			t.Errorf("value %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestIndicators(t *testing.T) {
+	nan := math.NaN()
+
+	// Wilder's RSI worksheet data, as popularised by StockCharts; the
+	// expected values are the unrounded ones TA-Lib produces
+	rsiCloses := []float64{
+		44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+		45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+	}
+	rsiWant := make([]float64, 14, len(rsiCloses))
+	for i := range rsiWant {
+		rsiWant[i] = nan
+	}
+	rsiWant = append(rsiWant, 70.464, 66.250, 66.481, 69.347, 66.295, 57.915)
+
+	tests := []struct {
+		name      string
+		indicator Indicator
+		values    []float64
+		want      []float64
+		tolerance float64
+	}{
+		{
+			name:      "SMA",
+			indicator: NewSMA(3),
+			values:    []float64{1, 2, 3, 4, 5},
+			want:      []float64{nan, nan, 2, 3, 4},
+			tolerance: 1e-12,
+		},
+		{
+			name:      "EMA seeded with the simple average",
+			indicator: NewEMA(3),
+			values:    []float64{1, 2, 3, 4, 5, 2},
+			want:      []float64{nan, nan, 2, 3, 4, 3},
+			tolerance: 1e-12,
+		},
+		{
+			name:      "RSI",
+			indicator: NewRSI(14),
+			values:    rsiCloses,
+			want:      rsiWant,
+			tolerance: 1e-3,
+		},
+		{
+			name:      "RSI of a flat series",
+			indicator: NewRSI(2),
+			values:    []float64{5, 5, 5},
+			want:      []float64{nan, nan, 50},
+			tolerance: 1e-12,
+		},
+		{
+			name:      "RSI without losses",
+			indicator: NewRSI(2),
+			values:    []float64{1, 2, 3},
+			want:      []float64{nan, nan, 100},
+			tolerance: 1e-12,
+		},
+		{
+			name:      "MACD line",
+			indicator: NewMACD(2, 3, 2),
+			values:    []float64{1, 2, 3, 4, 5, 6, 4},
+			want:      []float64{nan, nan, nan, 0.5, 0.5, 0.5, 0},
+			tolerance: 1e-12,
+		},
+		{
+			name:      "Bollinger middle line",
+			indicator: NewBollinger(3, 2),
+			values:    []float64{1, 2, 3, 4},
+			want:      []float64{nan, nan, 2, 3},
+			tolerance: 1e-12,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSeries(t, Apply(tt.indicator, tt.values), tt.want, tt.tolerance)
+		})
+	}
+}
+
+func TestMACDValue(t *testing.T) {
+	m := NewMACD(2, 3, 2)
+	Apply(m, []float64{1, 2, 3, 4, 5, 6, 4})
+
+	// Fast EMA 4.5, slow EMA 4.5, signal 0.5 + 2/3*(0-0.5)
+	want := MACDValue{MACD: 0, Signal: 1.0 / 6, Histogram: -1.0 / 6}
+	got := m.Value()
+	if math.Abs(got.MACD-want.MACD) > 1e-12 || math.Abs(got.Signal-want.Signal) > 1e-12 ||
+		math.Abs(got.Histogram-want.Histogram) > 1e-12 {
+		t.Errorf("Value() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBollingerBands(t *testing.T) {
+	// Population standard deviation 2 around a mean of 5
+	b := NewBollinger(8, 2)
+	Apply(b, []float64{2, 4, 4, 4, 5, 5, 7, 9})
+
+	want := Band{Upper: 9, Middle: 5, Lower: 1}
+	if got := b.Value(); math.Abs(got.Upper-want.Upper) > 1e-9 || got.Middle != want.Middle ||
+		math.Abs(got.Lower-want.Lower) > 1e-9 {
+		t.Errorf("Value() = %+v, want %+v", got, want)
+	}
+	if got := b.Value().Width(); math.Abs(got-1.6) > 1e-9 {
+		t.Errorf("Width() = %v, want 1.6", got)
+	}
+}
+
+func TestATR(t *testing.T) {
+	candles := []mudrex.Candle{
+		{High: 10, Low: 8, Close: 9},
+		{High: 11, Low: 9, Close: 10},  // TR 2
+		{High: 12, Low: 10, Close: 11}, // TR 2
+		{High: 14, Low: 10, Close: 13}, // TR 4, the range
+		{High: 13, Low: 9, Close: 12},  // TR 4, from the previous close
+		{High: 20, Low: 18, Close: 19}, // TR 8, a gap up
+	}
+	nan := math.NaN()
+	want := []float64{nan, nan, nan, 8.0 / 3, 28.0 / 9, 128.0 / 27}
+
+	assertSeries(t, ApplyCandles(NewATR(3), candles), want, 1e-12)
+}
+
+func TestVWAP(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := []mudrex.Candle{
+		{Time: start, High: 11, Low: 9, Close: 10, Volume: 1},                      // typical 10
+		{Time: start.Add(time.Hour), High: 22, Low: 18, Close: 20, Volume: 3},      // typical 20
+		{Time: start.Add(24 * time.Hour), High: 31, Low: 29, Close: 30, Volume: 2}, // new session
+	}
+
+	tests := []struct {
+		name    string
+		session time.Duration
+		want    []float64
+	}{
+		{"without sessions", 0, []float64{10, 17.5, 130.0 / 6}},
+		{"daily sessions", 24 * time.Hour, []float64{10, 17.5, 30}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSeries(t, ApplyCandles(NewVWAP(tt.session), candles), tt.want, 1e-12)
+		})
+	}
+}
+
+func TestNonPositivePeriodPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewSMA(0) did not panic")
+		}
+	}()
+	NewSMA(0)
+}
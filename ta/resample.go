@@ -0,0 +1,103 @@
+// Package ta provides candle resampling and technical indicators over
+// mudrex.Candle series. Indicators are incremental: feed them one value or
+// candle at a time, from a backtest strategy or a live price poll, and read
+// the latest value once they are warmed up.
+package ta
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// Aggregator builds candles of a fixed interval from finer candles or price
+// ticks. Buckets are aligned to the interval since the Unix epoch, so hourly
+// candles start on the hour.
+type Aggregator struct {
+	interval time.Duration
+	current  mudrex.Candle
+	open     bool
+}
+
+// NewAggregator creates an aggregator of interval-long candles
+func NewAggregator(interval time.Duration) (*Aggregator, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive")
+	}
+	return &Aggregator{interval: interval}, nil
+}
+
+// Add merges c into the candle under construction. When c starts a new
+// bucket the finished candle is returned with true. Candles older than the
+// current bucket are ignored.
+func (a *Aggregator) Add(c mudrex.Candle) (mudrex.Candle, bool) {
+	start := c.Time.Truncate(a.interval)
+	if !a.open {
+		a.start(c, start)
+		return mudrex.Candle{}, false
+	}
+
+	switch {
+	case start.Equal(a.current.Time):
+		a.current.High = math.Max(a.current.High, c.High)
+		a.current.Low = math.Min(a.current.Low, c.Low)
+		a.current.Close = c.Close
+		a.current.Volume += c.Volume
+		return mudrex.Candle{}, false
+	case start.After(a.current.Time):
+		done := a.current
+		a.start(c, start)
+		return done, true
+	default:
+		return mudrex.Candle{}, false
+	}
+}
+
+// AddPrice merges a single trade or polled price, such as a position's mark
+// price, into the candle under construction
+func (a *Aggregator) AddPrice(t time.Time, price, volume float64) (mudrex.Candle, bool) {
+	return a.Add(mudrex.Candle{Time: t, Open: price, High: price, Low: price, Close: price, Volume: volume})
+}
+
+// Current returns the candle under construction
+func (a *Aggregator) Current() (mudrex.Candle, bool) {
+	return a.current, a.open
+}
+
+// Flush returns the candle under construction and starts afresh
+func (a *Aggregator) Flush() (mudrex.Candle, bool) {
+	c, ok := a.current, a.open
+	a.current, a.open = mudrex.Candle{}, false
+	return c, ok
+}
+
+func (a *Aggregator) start(c mudrex.Candle, start time.Time) {
+	a.current = c
+	a.current.Time = start
+	a.open = true
+}
+
+// Resample converts candles, sorted oldest first, to interval-long candles.
+// The last candle may be incomplete.
+func Resample(candles []mudrex.Candle, interval time.Duration) ([]mudrex.Candle, error) {
+	agg, err := NewAggregator(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []mudrex.Candle
+	for i, c := range candles {
+		if i > 0 && c.Time.Before(candles[i-1].Time) {
+			return nil, fmt.Errorf("candles must be sorted oldest first: candle %d at %s precedes %s", i, c.Time, candles[i-1].Time)
+		}
+		if done, ok := agg.Add(c); ok {
+			out = append(out, done)
+		}
+	}
+	if last, ok := agg.Flush(); ok {
+		out = append(out, last)
+	}
+	return out, nil
+}
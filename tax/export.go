@@ -0,0 +1,106 @@
+package tax
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// PeriodColumns is the CSV header for WriteCSV
+var PeriodColumns = []string{
+	"period", "asset_id", "symbol", "disposals", "realized_gain", "fees", "adjustments", "net",
+}
+
+// DisposalColumns is the CSV header for WriteDisposalsCSV
+var DisposalColumns = []string{
+	"period", "asset_id", "symbol", "side", "quantity", "open_price", "close_price",
+	"opened_at", "closed_at", "open_order_id", "close_order_id", "gain",
+}
+
+// WriteCSV writes one row per period and asset
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(PeriodColumns); err != nil {
+		return err
+	}
+	for _, s := range r.Periods {
+		record := []string{
+			s.Period, s.AssetID, s.Symbol, strconv.Itoa(s.Disposals),
+			format(s.RealizedGain), format(s.Fees), format(s.Adjustments), format(s.Net),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteDisposalsCSV writes one row per matched lot quantity
+func (r *Report) WriteDisposalsCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(DisposalColumns); err != nil {
+		return err
+	}
+	for _, d := range r.Disposals {
+		record := []string{
+			d.Period, d.AssetID, d.Symbol, string(d.Side), format(d.Quantity),
+			format(d.OpenPrice), format(d.ClosePrice),
+			d.OpenedAt.UTC().Format(time.RFC3339), d.ClosedAt.UTC().Format(time.RFC3339),
+			d.OpenOrderID, d.CloseOrderID, format(d.Gain),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSummary writes a human-readable report
+func (r *Report) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "Realized gains by %s (%s)\n\n", periodName(r.Period), r.Method)
+	fmt.Fprintln(tw, "PERIOD\tASSET\tDISPOSALS\tREALIZED\tFEES\tADJUSTMENTS\tNET\t")
+	for _, s := range r.Periods {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			s.Period, s.AssetID, s.Disposals, s.RealizedGain, s.Fees, s.Adjustments, s.Net)
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+		len(r.Disposals), r.RealizedGain, r.Fees, r.Adjustments, r.Net)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.OpenLots) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\nOpen lots\n\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ASSET\tSIDE\tQUANTITY\tPRICE\tOPENED")
+	for _, l := range r.OpenLots {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			l.AssetID, l.Side, format(l.Quantity), format(l.Price), l.OpenedAt.UTC().Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
+
+func periodName(p Period) string {
+	switch p {
+	case PeriodDay:
+		return "day"
+	case PeriodQuarter:
+		return "quarter"
+	case PeriodYear:
+		return "year"
+	default:
+		return "month"
+	}
+}
+
+func format(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
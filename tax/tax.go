@@ -0,0 +1,372 @@
+// Package tax matches filled orders into lots per asset and reports realized
+// gains, fees and adjustments per period for capital-gains style reporting.
+package tax
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// Method selects how closing fills are matched against open lots
+type Method string
+
+const (
+	// FIFO closes the oldest open lot first
+	FIFO Method = "FIFO"
+	// LIFO closes the newest open lot first
+	LIFO Method = "LIFO"
+	// AverageCost pools open quantity at its weighted average price
+	AverageCost Method = "AVERAGE_COST"
+)
+
+// Period is the reporting granularity
+type Period string
+
+const (
+	PeriodDay     Period = "DAY"
+	PeriodMonth   Period = "MONTH"
+	PeriodQuarter Period = "QUARTER"
+	PeriodYear    Period = "YEAR"
+)
+
+// Side is the direction of a lot
+type Side string
+
+const (
+	SideLong  Side = "LONG"
+	SideShort Side = "SHORT"
+)
+
+// quantityEpsilon absorbs floating point residue when lots are matched
+const quantityEpsilon = 1e-12
+
+// Adjustment is a cash flow that is not a fill or trading fee, such as a
+// funding payment. Positive amounts are income.
+type Adjustment struct {
+	AssetID string    `json:"asset_id"`
+	Kind    string    `json:"kind"`
+	Amount  float64   `json:"amount"`
+	Time    time.Time `json:"time"`
+}
+
+// Config controls report generation
+type Config struct {
+	// Method defaults to FIFO
+	Method Method
+	// Period defaults to PeriodMonth
+	Period Period
+	// Location for period boundaries (default UTC)
+	Location *time.Location
+}
+
+// Lot is an open quantity acquired by one or more fills
+type Lot struct {
+	AssetID  string    `json:"asset_id"`
+	Symbol   string    `json:"symbol"`
+	Side     Side      `json:"side"`
+	Quantity float64   `json:"quantity"`
+	Price    float64   `json:"price"`
+	OpenedAt time.Time `json:"opened_at"`
+	OrderID  string    `json:"order_id"`
+}
+
+// Disposal is a quantity of a lot closed by an opposing fill
+type Disposal struct {
+	Period       string    `json:"period"`
+	AssetID      string    `json:"asset_id"`
+	Symbol       string    `json:"symbol"`
+	Side         Side      `json:"side"`
+	Quantity     float64   `json:"quantity"`
+	OpenPrice    float64   `json:"open_price"`
+	ClosePrice   float64   `json:"close_price"`
+	OpenedAt     time.Time `json:"opened_at"`
+	ClosedAt     time.Time `json:"closed_at"`
+	OpenOrderID  string    `json:"open_order_id"`
+	CloseOrderID string    `json:"close_order_id"`
+	Gain         float64   `json:"gain"`
+}
+
+// PeriodSummary totals one asset over one period
+type PeriodSummary struct {
+	Period       string    `json:"period"`
+	Start        time.Time `json:"start"`
+	AssetID      string    `json:"asset_id"`
+	Symbol       string    `json:"symbol"`
+	Disposals    int       `json:"disposals"`
+	RealizedGain float64   `json:"realized_gain"`
+	Fees         float64   `json:"fees"`
+	Adjustments  float64   `json:"adjustments"`
+	// Net is realized gain minus fees plus adjustments
+	Net float64 `json:"net"`
+}
+
+// Report is the outcome of lot matching
+type Report struct {
+	Method    Method          `json:"method"`
+	Period    Period          `json:"period"`
+	Periods   []PeriodSummary `json:"periods"`
+	Disposals []Disposal      `json:"disposals"`
+	OpenLots  []Lot           `json:"open_lots"`
+
+	RealizedGain float64 `json:"realized_gain"`
+	Fees         float64 `json:"fees"`
+	Adjustments  float64 `json:"adjustments"`
+	Net          float64 `json:"net"`
+}
+
+// fill is the executed part of an order, signed positive for LONG
+type fill struct {
+	assetID  string
+	symbol   string
+	orderID  string
+	quantity float64
+	price    float64
+	time     time.Time
+}
+
+// Generate matches the fills in orders into lots and totals realized gains,
+// fees and adjustments per period and asset. Orders without a filled quantity
+// are ignored.
+func Generate(orders []mudrex.Order, fees []mudrex.FeeRecord, adjustments []Adjustment, cfg Config) (*Report, error) {
+	if cfg.Method == "" {
+		cfg.Method = FIFO
+	}
+	if cfg.Period == "" {
+		cfg.Period = PeriodMonth
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	switch cfg.Method {
+	case FIFO, LIFO, AverageCost:
+	default:
+		return nil, fmt.Errorf("unknown lot method %q", cfg.Method)
+	}
+	switch cfg.Period {
+	case PeriodDay, PeriodMonth, PeriodQuarter, PeriodYear:
+	default:
+		return nil, fmt.Errorf("unknown period %q", cfg.Period)
+	}
+
+	fills, err := fillsFrom(orders)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{Method: cfg.Method, Period: cfg.Period}
+	summaries := make(map[summaryKey]*PeriodSummary)
+	summary := func(t time.Time, assetID, symbol string) *PeriodSummary {
+		start, label := periodOf(t, cfg.Period, cfg.Location)
+		key := summaryKey{label, assetID}
+		s, ok := summaries[key]
+		if !ok {
+			s = &PeriodSummary{Period: label, Start: start, AssetID: assetID}
+			summaries[key] = s
+		}
+		if s.Symbol == "" {
+			s.Symbol = symbol
+		}
+		return s
+	}
+
+	inventory := make(map[string][]Lot)
+	for _, f := range fills {
+		lots := inventory[f.assetID]
+		remaining := f.quantity
+
+		for len(lots) > 0 && math.Abs(remaining) > quantityEpsilon && opposes(lots[0].Side, remaining) {
+			i := 0
+			if cfg.Method == LIFO {
+				i = len(lots) - 1
+			}
+			lot := &lots[i]
+
+			qty := math.Min(math.Abs(remaining), lot.Quantity)
+			gain := (f.price - lot.Price) * qty
+			if lot.Side == SideShort {
+				gain = -gain
+			}
+
+			s := summary(f.time, f.assetID, f.symbol)
+			s.Disposals++
+			s.RealizedGain += gain
+			r.Disposals = append(r.Disposals, Disposal{
+				Period:       s.Period,
+				AssetID:      f.assetID,
+				Symbol:       f.symbol,
+				Side:         lot.Side,
+				Quantity:     qty,
+				OpenPrice:    lot.Price,
+				ClosePrice:   f.price,
+				OpenedAt:     lot.OpenedAt,
+				ClosedAt:     f.time,
+				OpenOrderID:  lot.OrderID,
+				CloseOrderID: f.orderID,
+				Gain:         gain,
+			})
+
+			lot.Quantity -= qty
+			if remaining > 0 {
+				remaining -= qty
+			} else {
+				remaining += qty
+			}
+			if lot.Quantity <= quantityEpsilon {
+				lots = append(lots[:i], lots[i+1:]...)
+			}
+		}
+
+		if math.Abs(remaining) > quantityEpsilon {
+			side := SideLong
+			if remaining < 0 {
+				side = SideShort
+			}
+			lot := Lot{
+				AssetID:  f.assetID,
+				Symbol:   f.symbol,
+				Side:     side,
+				Quantity: math.Abs(remaining),
+				Price:    f.price,
+				OpenedAt: f.time,
+				OrderID:  f.orderID,
+			}
+			if cfg.Method == AverageCost && len(lots) > 0 {
+				pooled := &lots[0]
+				total := pooled.Quantity + lot.Quantity
+				pooled.Price = (pooled.Price*pooled.Quantity + lot.Price*lot.Quantity) / total
+				pooled.Quantity = total
+			} else {
+				lots = append(lots, lot)
+			}
+		}
+		inventory[f.assetID] = lots
+	}
+
+	for _, fee := range fees {
+		amount, err := mudrex.ParseDecimal("fee_amount", fee.FeeAmount)
+		if err != nil {
+			return nil, fmt.Errorf("fee for order %s: %w", fee.OrderID, err)
+		}
+		summary(fee.CreatedAt.Time, fee.AssetID, fee.Symbol).Fees += amount
+	}
+	for _, a := range adjustments {
+		summary(a.Time, a.AssetID, "").Adjustments += a.Amount
+	}
+
+	for _, s := range summaries {
+		s.Net = s.RealizedGain - s.Fees + s.Adjustments
+		r.Periods = append(r.Periods, *s)
+		r.RealizedGain += s.RealizedGain
+		r.Fees += s.Fees
+		r.Adjustments += s.Adjustments
+	}
+	r.Net = r.RealizedGain - r.Fees + r.Adjustments
+	sort.Slice(r.Periods, func(i, j int) bool {
+		if !r.Periods[i].Start.Equal(r.Periods[j].Start) {
+			return r.Periods[i].Start.Before(r.Periods[j].Start)
+		}
+		return r.Periods[i].AssetID < r.Periods[j].AssetID
+	})
+
+	assets := make([]string, 0, len(inventory))
+	for assetID := range inventory {
+		assets = append(assets, assetID)
+	}
+	sort.Strings(assets)
+	for _, assetID := range assets {
+		r.OpenLots = append(r.OpenLots, inventory[assetID]...)
+	}
+
+	return r, nil
+}
+
+type summaryKey struct {
+	period  string
+	assetID string
+}
+
+// fillsFrom extracts the executed quantity of each order, oldest first
+func fillsFrom(orders []mudrex.Order) ([]fill, error) {
+	var fills []fill
+	for _, o := range orders {
+		qty, err := mudrex.ParseDecimal("filled_quantity", o.FilledQuantity)
+		if err != nil {
+			return nil, fmt.Errorf("order %s: %w", o.OrderID, err)
+		}
+		if qty == 0 && o.Status == mudrex.OrderStatusFilled {
+			if qty, err = mudrex.ParseDecimal("quantity", o.Quantity); err != nil {
+				return nil, fmt.Errorf("order %s: %w", o.OrderID, err)
+			}
+		}
+		if qty <= 0 {
+			continue
+		}
+
+		priceValue := o.AvgFilledPrice
+		if priceValue == "" {
+			priceValue = o.Price
+		}
+		price, err := mudrex.ParseDecimal("price", priceValue)
+		if err != nil || price <= 0 {
+			return nil, fmt.Errorf("order %s: no usable fill price", o.OrderID)
+		}
+
+		if o.OrderType == mudrex.OrderTypeShort {
+			qty = -qty
+		}
+		assetID := o.AssetID
+		if assetID == "" {
+			assetID = o.Symbol
+		}
+		fills = append(fills, fill{
+			assetID:  assetID,
+			symbol:   o.Symbol,
+			orderID:  o.OrderID,
+			quantity: qty,
+			price:    price,
+			time:     fillTime(o),
+		})
+	}
+
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].time.Before(fills[j].time) })
+	return fills, nil
+}
+
+// fillTime approximates when o filled: orders carry no execution timestamp,
+// but UpdatedAt is set by the last fill of a filled order, while CreatedAt is
+// when a resting order was placed
+func fillTime(o mudrex.Order) time.Time {
+	if !o.UpdatedAt.IsZero() && !o.UpdatedAt.Before(o.CreatedAt.Time) {
+		return o.UpdatedAt.Time
+	}
+	return o.CreatedAt.Time
+}
+
+// opposes reports whether a signed fill quantity closes lots on side
+func opposes(side Side, quantity float64) bool {
+	return (side == SideLong && quantity < 0) || (side == SideShort && quantity > 0)
+}
+
+// periodOf returns the start and label of the period containing t
+func periodOf(t time.Time, period Period, loc *time.Location) (time.Time, string) {
+	t = t.In(loc)
+	switch period {
+	case PeriodDay:
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		return start, start.Format("2006-01-02")
+	case PeriodQuarter:
+		q := (int(t.Month()) - 1) / 3
+		start := time.Date(t.Year(), time.Month(q*3+1), 1, 0, 0, 0, 0, loc)
+		return start, fmt.Sprintf("%d-Q%d", t.Year(), q+1)
+	case PeriodYear:
+		start := time.Date(t.Year(), 1, 1, 0, 0, 0, 0, loc)
+		return start, start.Format("2006")
+	default:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		return start, start.Format("2006-01")
+	}
+}
@@ -0,0 +1,190 @@
+package tax
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2024, month, d, 12, 0, 0, 0, time.UTC)
+}
+
+func filled(id string, side mudrex.OrderType, quantity, price string, at time.Time) mudrex.Order {
+	return mudrex.Order{
+		OrderID:        id,
+		AssetID:        "BTCUSDT",
+		Symbol:         "BTCUSDT",
+		OrderType:      side,
+		Status:         mudrex.OrderStatusFilled,
+		Quantity:       quantity,
+		FilledQuantity: quantity,
+		AvgFilledPrice: price,
+		CreatedAt:      mudrex.NewTimestamp(at),
+		UpdatedAt:      mudrex.NewTimestamp(at),
+	}
+}
+
+func TestGenerateMethods(t *testing.T) {
+	orders := []mudrex.Order{
+		filled("buy-1", mudrex.OrderTypeLong, "1", "100", day(time.January, 10)),
+		filled("buy-2", mudrex.OrderTypeLong, "1", "200", day(time.January, 20)),
+		filled("sell", mudrex.OrderTypeShort, "1", "300", day(time.February, 5)),
+	}
+
+	tests := []struct {
+		method    Method
+		gain      float64
+		openPrice float64
+	}{
+		{FIFO, 200, 200},
+		{LIFO, 100, 100},
+		{AverageCost, 150, 150},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			r, err := Generate(orders, nil, nil, Config{Method: tt.method})
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if r.RealizedGain != tt.gain {
+				t.Errorf("RealizedGain = %v, want %v", r.RealizedGain, tt.gain)
+			}
+			if len(r.OpenLots) != 1 || r.OpenLots[0].Quantity != 1 || r.OpenLots[0].Price != tt.openPrice {
+				t.Errorf("OpenLots = %+v, want 1 at %v", r.OpenLots, tt.openPrice)
+			}
+			if len(r.Periods) != 1 || r.Periods[0].Period != "2024-02" {
+				t.Errorf("Periods = %+v, want one for 2024-02", r.Periods)
+			}
+		})
+	}
+}
+
+func TestGenerateShortAndReversal(t *testing.T) {
+	orders := []mudrex.Order{
+		filled("short", mudrex.OrderTypeShort, "2", "100", day(time.March, 1)),
+		filled("cover", mudrex.OrderTypeLong, "3", "80", day(time.March, 2)),
+	}
+
+	r, err := Generate(orders, nil, nil, Config{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	// Covering 2 short at 80 gains 40; the extra 1 opens a long lot
+	if r.RealizedGain != 40 {
+		t.Errorf("RealizedGain = %v, want 40", r.RealizedGain)
+	}
+	if len(r.Disposals) != 1 || r.Disposals[0].Side != SideShort || r.Disposals[0].Quantity != 2 {
+		t.Errorf("Disposals = %+v, want one short disposal of 2", r.Disposals)
+	}
+	if len(r.OpenLots) != 1 || r.OpenLots[0].Side != SideLong || r.OpenLots[0].Quantity != 1 ||
+		r.OpenLots[0].OrderID != "cover" {
+		t.Errorf("OpenLots = %+v, want a long lot of 1 from cover", r.OpenLots)
+	}
+}
+
+func TestGenerateFillTime(t *testing.T) {
+	// A limit order placed in January that filled in February belongs to
+	// February
+	sell := filled("sell", mudrex.OrderTypeShort, "1", "150", day(time.January, 31))
+	sell.UpdatedAt = mudrex.NewTimestamp(day(time.February, 1))
+	orders := []mudrex.Order{
+		filled("buy", mudrex.OrderTypeLong, "1", "100", day(time.January, 5)),
+		sell,
+	}
+
+	r, err := Generate(orders, nil, nil, Config{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(r.Disposals) != 1 || r.Disposals[0].Period != "2024-02" || !r.Disposals[0].ClosedAt.Equal(day(time.February, 1)) {
+		t.Errorf("Disposals = %+v, want one closed on 2024-02-01", r.Disposals)
+	}
+}
+
+func TestGenerateTotals(t *testing.T) {
+	orders := []mudrex.Order{
+		filled("buy", mudrex.OrderTypeLong, "2", "100", day(time.April, 1)),
+		filled("sell", mudrex.OrderTypeShort, "2", "110", day(time.May, 1)),
+	}
+	fees := []mudrex.FeeRecord{
+		{OrderID: "buy", AssetID: "BTCUSDT", FeeAmount: "0.5", CreatedAt: mudrex.NewTimestamp(day(time.April, 1))},
+		{OrderID: "sell", AssetID: "BTCUSDT", FeeAmount: "0.75", CreatedAt: mudrex.NewTimestamp(day(time.May, 1))},
+	}
+	adjustments := []Adjustment{
+		{AssetID: "BTCUSDT", Kind: "funding", Amount: -1.25, Time: day(time.May, 2)},
+	}
+
+	r, err := Generate(orders, fees, adjustments, Config{Period: PeriodQuarter})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if r.RealizedGain != 20 || r.Fees != 1.25 || r.Adjustments != -1.25 || r.Net != 17.5 {
+		t.Errorf("totals = %v gain, %v fees, %v adjustments, %v net; want 20, 1.25, -1.25, 17.5",
+			r.RealizedGain, r.Fees, r.Adjustments, r.Net)
+	}
+	if len(r.Periods) != 1 || r.Periods[0].Period != "2024-Q2" || r.Periods[0].Net != 17.5 {
+		t.Errorf("Periods = %+v, want one 2024-Q2 period netting 17.5", r.Periods)
+	}
+}
+
+func TestGenerateIgnoresUnfilledOrders(t *testing.T) {
+	open := filled("open", mudrex.OrderTypeLong, "1", "100", day(time.June, 1))
+	open.Status = mudrex.OrderStatusOpen
+	open.FilledQuantity = "0"
+
+	r, err := Generate([]mudrex.Order{open}, nil, nil, Config{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(r.OpenLots) != 0 || len(r.Disposals) != 0 {
+		t.Errorf("report = %+v, want no lots or disposals", r)
+	}
+}
+
+func TestGenerateRejectsBadConfig(t *testing.T) {
+	for _, cfg := range []Config{{Method: "HIFO"}, {Period: "WEEK"}} {
+		if _, err := Generate(nil, nil, nil, cfg); err == nil {
+			t.Errorf("Generate(%+v) succeeded, want error", cfg)
+		}
+	}
+}
+
+func TestPeriodOf(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+	at := time.Date(2024, 12, 31, 20, 0, 0, 0, time.UTC) // 2025-01-01 01:30 IST
+
+	tests := []struct {
+		period Period
+		loc    *time.Location
+		label  string
+	}{
+		{PeriodDay, time.UTC, "2024-12-31"},
+		{PeriodDay, kolkata, "2025-01-01"},
+		{PeriodMonth, time.UTC, "2024-12"},
+		{PeriodQuarter, time.UTC, "2024-Q4"},
+		{PeriodQuarter, kolkata, "2025-Q1"},
+		{PeriodYear, time.UTC, "2024"},
+	}
+	for _, tt := range tests {
+		if _, label := periodOf(at, tt.period, tt.loc); label != tt.label {
+			t.Errorf("periodOf(%s, %s) = %q, want %q", tt.period, tt.loc, label, tt.label)
+		}
+	}
+}
+
+func TestAverageCostPoolsPrice(t *testing.T) {
+	orders := []mudrex.Order{
+		filled("buy-1", mudrex.OrderTypeLong, "1", "100", day(time.July, 1)),
+		filled("buy-2", mudrex.OrderTypeLong, "3", "200", day(time.July, 2)),
+	}
+	r, err := Generate(orders, nil, nil, Config{Method: AverageCost})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(r.OpenLots) != 1 || r.OpenLots[0].Quantity != 4 || math.Abs(r.OpenLots[0].Price-175) > 1e-9 {
+		t.Errorf("OpenLots = %+v, want 4 at 175", r.OpenLots)
+	}
+}
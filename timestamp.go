@@ -0,0 +1,163 @@
+package mudrex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampEncoding records how a Timestamp appeared in JSON so it can be
+// written back the same way
+type timestampEncoding uint8
+
+const (
+	encodingNone timestampEncoding = iota
+	encodingNull
+	encodingEmpty
+	encodingText
+	encodingSeconds
+	encodingMillis
+)
+
+// Timestamp is a time decoded from any of the representations the API uses:
+// an RFC 3339 string, epoch seconds or milliseconds (as a number or a numeric
+// string), an empty string or null. Empty and null decode to the zero time.
+// Marshalling reproduces the original representation, so decoded values
+// round-trip unchanged; Timestamps built with NewTimestamp marshal as
+// RFC 3339. It embeds time.Time, so Before, After, IsZero and friends work
+// directly.
+type Timestamp struct {
+	time.Time
+
+	encoding timestampEncoding
+	quoted   bool
+	raw      string
+	decoded  time.Time
+}
+
+// NewTimestamp wraps t
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp{Time: t, encoding: encodingText}
+}
+
+// textLayouts are tried in order for string timestamps that are not numeric
+var textLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// millisThreshold separates epoch seconds from milliseconds: 1e11 seconds is
+// in the year 5138, while 1e11 milliseconds is in 1973
+const millisThreshold = 1e11
+
+// UnmarshalJSON decodes RFC 3339 strings, epoch seconds or milliseconds,
+// empty strings and null
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	*t = Timestamp{}
+
+	if bytes.Equal(data, []byte("null")) {
+		t.encoding = encodingNull
+		return nil
+	}
+
+	text := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return fmt.Errorf("invalid timestamp %s: %w", data, err)
+		}
+		t.quoted = true
+		if strings.TrimSpace(text) == "" {
+			t.encoding = encodingEmpty
+			return nil
+		}
+	}
+
+	if epoch, err := strconv.ParseFloat(text, 64); err == nil {
+		t.raw = text
+		if math.Abs(epoch) >= millisThreshold {
+			t.encoding = encodingMillis
+			t.Time = time.UnixMilli(int64(epoch)).UTC()
+		} else {
+			t.encoding = encodingSeconds
+			t.Time = time.Unix(splitEpoch(text, epoch)).UTC()
+		}
+		t.decoded = t.Time
+		return nil
+	}
+	if !t.quoted {
+		return fmt.Errorf("invalid timestamp %s", data)
+	}
+
+	for _, layout := range textLayouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			t.Time = parsed
+			t.encoding = encodingText
+			t.raw = text
+			t.decoded = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid timestamp %q", text)
+}
+
+// splitEpoch splits epoch seconds into whole seconds and nanoseconds,
+// reading plain decimals from their digits since a float64 epoch only keeps
+// about a microsecond of precision
+func splitEpoch(text string, epoch float64) (int64, int64) {
+	whole, fraction, ok := strings.Cut(text, ".")
+	if ok && !strings.ContainsAny(text, "eE") {
+		sec, errSec := strconv.ParseInt(whole, 10, 64)
+		nsec, errNsec := strconv.ParseInt((fraction + "000000000")[:9], 10, 64)
+		if errSec == nil && errNsec == nil {
+			if strings.HasPrefix(whole, "-") {
+				nsec = -nsec
+			}
+			return sec, nsec
+		}
+	}
+	sec, frac := math.Modf(epoch)
+	return int64(sec), int64(math.Round(frac * 1e9))
+}
+
+// MarshalJSON writes the timestamp in the representation it was decoded
+// from, or RFC 3339 for timestamps created in code. The original text is
+// reproduced exactly unless the time has since been changed. A zero
+// Timestamp that was not decoded marshals as null.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.raw != "" && t.Time.Equal(t.decoded) {
+		if t.quoted {
+			return json.Marshal(t.raw)
+		}
+		return []byte(t.raw), nil
+	}
+
+	var epoch string
+	switch t.encoding {
+	case encodingNull, encodingNone:
+		if t.Time.IsZero() {
+			return []byte("null"), nil
+		}
+	case encodingEmpty:
+		if t.Time.IsZero() {
+			return []byte(`""`), nil
+		}
+	case encodingSeconds:
+		epoch = strconv.FormatInt(t.Unix(), 10)
+	case encodingMillis:
+		epoch = strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	if epoch != "" {
+		if t.quoted {
+			return json.Marshal(epoch)
+		}
+		return []byte(epoch), nil
+	}
+	return json.Marshal(t.Time.Format(time.RFC3339Nano))
+}
@@ -0,0 +1,113 @@
+package mudrex
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestampRoundTrip(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)
+	atMillis := at.Add(123 * time.Millisecond)
+
+	tests := []struct {
+		name string
+		json string
+		want time.Time
+	}{
+		{"RFC 3339", `"2024-03-01T12:30:45Z"`, at},
+		{"RFC 3339 with offset and fraction", `"2024-03-01T18:00:45.123+05:30"`, atMillis},
+		{"RFC 3339 without zone", `"2024-03-01T12:30:45"`, at},
+		{"space separated", `"2024-03-01 12:30:45"`, at},
+		{"epoch seconds", `1709296245`, at},
+		{"quoted epoch seconds", `"1709296245"`, at},
+		{"fractional epoch seconds", `1709296245.123`, atMillis},
+		{"nanosecond epoch seconds", `1709296245.123456789`, at.Add(123456789)},
+		{"sub-nanosecond epoch seconds", `1709296245.9999999999`, at.Add(999999999)},
+		{"epoch milliseconds", `1709296245123`, atMillis},
+		{"quoted epoch milliseconds", `"1709296245123"`, atMillis},
+		{"empty string", `""`, time.Time{}},
+		{"null", `null`, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ts Timestamp
+			if err := json.Unmarshal([]byte(tt.json), &ts); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !ts.Equal(tt.want) {
+				t.Errorf("time = %v, want %v", ts.Time, tt.want)
+			}
+
+			out, err := json.Marshal(ts)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(out) != tt.json {
+				t.Errorf("Marshal = %s, want %s", out, tt.json)
+			}
+		})
+	}
+}
+
+func TestTimestampMarshalAfterChange(t *testing.T) {
+	later := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"epoch seconds keep their unit", `1709296245`, `1709337600`},
+		{"quoted epoch milliseconds stay quoted", `"1709296245123"`, `"1709337600000"`},
+		{"text becomes RFC 3339", `"2024-03-01 12:30:45"`, `"2024-03-02T00:00:00Z"`},
+		{"null becomes RFC 3339", `null`, `"2024-03-02T00:00:00Z"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ts Timestamp
+			if err := json.Unmarshal([]byte(tt.json), &ts); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			ts.Time = later
+			out, err := json.Marshal(ts)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Marshal = %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimestampCreatedInCode(t *testing.T) {
+	tests := []struct {
+		name string
+		ts   Timestamp
+		want string
+	}{
+		{"NewTimestamp", NewTimestamp(time.Date(2024, 3, 1, 12, 30, 45, 5e8, time.UTC)), `"2024-03-01T12:30:45.5Z"`},
+		{"zero value", Timestamp{}, `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := json.Marshal(tt.ts)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Marshal = %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimestampInvalid(t *testing.T) {
+	for _, input := range []string{`"yesterday"`, `true`, `{}`, `"2024-13-01T00:00:00Z"`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(input), &ts); err == nil {
+			t.Errorf("Unmarshal(%s) = %v, want error", input, ts.Time)
+		}
+	}
+}
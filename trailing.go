@@ -0,0 +1,222 @@
+package mudrex
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// ErrUnknownRiskOrder is returned by TrailingStop.Apply when a stop loss
+// creation whose outcome was unknown turns out to have succeeded. The order's
+// ID is not known, so Apply refuses to create another until SetRiskOrderID
+// supplies it.
+var ErrUnknownRiskOrder = errors.New("stop loss exists but its risk order ID is unknown")
+
+// TrailingStopConfig configures a TrailingStop. Exactly one of Distance and
+// Percent must be set.
+type TrailingStopConfig struct {
+	// Distance of the stop from the best price, in quote currency
+	Distance float64
+	// Percent is the distance as a percentage of the best price, e.g. 2 for 2%
+	Percent float64
+	// MinStep is the smallest stop move worth an API call
+	MinStep float64
+	// RiskOrderID is the ID of the position's existing stop loss order,
+	// which Apply then edits instead of creating a new one. It is required
+	// when the position already has a stop loss.
+	RiskOrderID string
+}
+
+// TrailingStop moves a position's stop loss behind the best mark price seen:
+// up for LONG positions, down for SHORT. The stop never loosens, and prices
+// come from a PriceCache, so a stale mark price leaves the stop untouched.
+type TrailingStop struct {
+	config     TrailingStopConfig
+	prices     *PriceCache
+	positionID string
+	assetID    string
+	side       OrderType
+
+	// apply serializes Apply, so concurrent calls cannot both create a stop
+	// loss order or revert each other's stop
+	apply sync.Mutex
+
+	mu          sync.Mutex
+	best        float64
+	stop        float64
+	riskOrderID string
+	// unresolved is set when creating the stop loss failed without a
+	// definitive rejection, so the order may exist on the server
+	unresolved bool
+}
+
+// NewTrailingStop creates a trailing stop for position. An existing stop
+// loss on the position is kept until the trail passes it, and must be
+// identified by config.RiskOrderID.
+func NewTrailingStop(prices *PriceCache, position *Position, config TrailingStopConfig) (*TrailingStop, error) {
+	if (config.Distance > 0) == (config.Percent > 0) {
+		return nil, validationError("exactly one of distance and percent must be positive")
+	}
+	if config.Percent >= 100 {
+		return nil, validationError("percent must be below 100")
+	}
+	if !position.Side.Valid() {
+		return nil, validationError("invalid position side " + string(position.Side))
+	}
+
+	t := &TrailingStop{
+		config:      config,
+		prices:      prices,
+		positionID:  position.PositionID,
+		assetID:     position.AssetID,
+		side:        position.Side,
+		riskOrderID: config.RiskOrderID,
+	}
+	if position.StopLoss != nil && *position.StopLoss != "" {
+		if config.RiskOrderID == "" {
+			return nil, validationError("position has a stop loss; set RiskOrderID so it is edited rather than duplicated")
+		}
+		stop, err := ParseDecimal("stop_loss", *position.StopLoss)
+		if err != nil {
+			return nil, err
+		}
+		t.stop = stop
+	}
+	return t, nil
+}
+
+// Stop returns the current stop price, zero before one is set
+func (t *TrailingStop) Stop() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stop
+}
+
+// SetRiskOrderID sets the ID of the position's stop loss order, which Apply
+// then edits. Use it to resolve ErrUnknownRiskOrder.
+func (t *TrailingStop) SetRiskOrderID(riskOrderID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.riskOrderID = riskOrderID
+	t.unresolved = false
+}
+
+// Update trails the stop behind the fresh mark price and reports whether it
+// moved. A stale or missing price returns the cache's error and changes
+// nothing.
+func (t *TrailingStop) Update() (float64, bool, error) {
+	mark, err := t.prices.Mark(t.assetID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	long := t.side == OrderTypeLong
+	if t.best == 0 || (long && mark > t.best) || (!long && mark < t.best) {
+		t.best = mark
+	}
+
+	distance := t.config.Distance
+	if t.config.Percent > 0 {
+		distance = t.best * t.config.Percent / 100
+	}
+	candidate := t.best - distance
+	if !long {
+		candidate = t.best + distance
+	}
+
+	tighter := t.stop == 0 || (long && candidate > t.stop) || (!long && candidate < t.stop)
+	if !tighter || math.Abs(candidate-t.stop) < t.config.MinStep {
+		return t.stop, false, nil
+	}
+	t.stop = candidate
+	return t.stop, true, nil
+}
+
+// Apply calls Update and, when the stop moved, sets or edits the position's
+// stop loss order. It returns the risk order, or nil if nothing changed. If
+// the API call fails the stop reverts, so the next Apply retries. When
+// creating the stop loss fails without a definitive rejection, e.g. on a
+// timeout, the next Apply first re-reads the position: with no stop loss on
+// it the creation is retried, otherwise the existing stop is adopted and
+// ErrUnknownRiskOrder returned rather than a duplicate created. Calls are
+// serialized, each holding the stop from Update through the API call.
+func (t *TrailingStop) Apply(positions *PositionsAPI) (*RiskOrder, error) {
+	t.apply.Lock()
+	defer t.apply.Unlock()
+
+	t.mu.Lock()
+	unresolved := t.unresolved
+	t.mu.Unlock()
+	if unresolved {
+		if err := t.resolve(positions); err != nil {
+			return nil, err
+		}
+	}
+
+	previous := t.Stop()
+	stop, moved, err := t.Update()
+	if err != nil || !moved {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	riskOrderID := t.riskOrderID
+	t.mu.Unlock()
+
+	price := strconv.FormatFloat(stop, 'f', -1, 64)
+	var order *RiskOrder
+	if riskOrderID == "" {
+		order, err = positions.SetStopLoss(t.positionID, price)
+	} else {
+		order, err = positions.EditRiskOrder(t.positionID, riskOrderID, price)
+	}
+	if err != nil {
+		t.mu.Lock()
+		if t.stop == stop {
+			t.stop = previous
+		}
+		if riskOrderID == "" && !IsRejected(err) {
+			t.unresolved = true
+		}
+		t.mu.Unlock()
+		return nil, err
+	}
+
+	if order.OrderID != "" {
+		t.mu.Lock()
+		t.riskOrderID = order.OrderID
+		t.mu.Unlock()
+	}
+	return order, nil
+}
+
+// resolve re-reads the position after a stop loss creation with an unknown
+// outcome. A position without a stop loss shows the creation failed, so
+// Apply may create one; otherwise the stop is adopted and
+// ErrUnknownRiskOrder returned.
+func (t *TrailingStop) resolve(positions *PositionsAPI) error {
+	position, err := positions.Get(t.positionID)
+	if err != nil {
+		return err
+	}
+	if position.StopLoss == nil || *position.StopLoss == "" {
+		t.mu.Lock()
+		t.unresolved = false
+		t.mu.Unlock()
+		return nil
+	}
+
+	stop, err := ParseDecimal("stop_loss", *position.StopLoss)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.stop = stop
+	t.mu.Unlock()
+	return fmt.Errorf("position %s: %w", t.positionID, ErrUnknownRiskOrder)
+}
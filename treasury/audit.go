@@ -0,0 +1,40 @@
+package treasury
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// AuditWriter returns an audit callback appending each record to w as a
+// JSON line. Write errors are reported to onError when it is non-nil.
+func AuditWriter(w io.Writer, onError func(error)) func(AuditRecord) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(r AuditRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := enc.Encode(r); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// ReadAudit reads JSON lines written by AuditWriter
+func ReadAudit(r io.Reader) ([]AuditRecord, error) {
+	var records []AuditRecord
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("audit line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
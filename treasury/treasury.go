@@ -0,0 +1,329 @@
+// Package treasury keeps the futures wallet balance within a configured band
+// by topping it up from spot when margin runs low and sweeping idle funds
+// back, subject to daily caps and cooldowns, with an audit record of every
+// transfer.
+package treasury
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	mudrex "github.com/DecentralizedJM/mudrex-go-sdk"
+)
+
+// Wallet is the part of the wallet API the engine uses. *mudrex.WalletAPI
+// implements it.
+type Wallet interface {
+	GetFuturesBalance() (*mudrex.FuturesBalance, error)
+	TransferWithKey(key string, fromWallet, toWallet mudrex.WalletType, amount string) (*mudrex.TransferResult, error)
+}
+
+var _ Wallet = (*mudrex.WalletAPI)(nil)
+
+// Direction of a transfer relative to the futures wallet
+type Direction string
+
+const (
+	// TopUp moves funds from spot to futures
+	TopUp Direction = "TOP_UP"
+	// Sweep moves funds from futures to spot
+	Sweep Direction = "SWEEP"
+)
+
+// Policy defines the band the free futures balance (balance less locked
+// amount) is kept in
+type Policy struct {
+	// MinBuffer is the free balance below which the wallet is topped up
+	MinBuffer float64
+	// MaxIdle is the free balance above which excess is swept to spot.
+	// Zero disables sweeping.
+	MaxIdle float64
+	// Target is the free balance a transfer aims for (default the midpoint
+	// of MinBuffer and MaxIdle, or MinBuffer when sweeping is disabled)
+	Target float64
+	// DailyTopUpCap and DailySweepCap bound the amount moved per day in each
+	// direction. Zero means unlimited.
+	DailyTopUpCap float64
+	DailySweepCap float64
+	// Cooldown is the minimum time between transfers
+	Cooldown time.Duration
+	// MinTransfer is the smallest amount worth transferring
+	MinTransfer float64
+	// Decimals amounts are rounded down to (default 2)
+	Decimals int
+}
+
+// Config configures an Engine
+type Config struct {
+	Policy Policy
+	// Interval between checks in Run (default 1m)
+	Interval time.Duration
+	// Location for daily cap boundaries (default UTC)
+	Location *time.Location
+	// History seeds caps and cooldowns with earlier audit records, e.g. from
+	// ReadAudit, so they survive restarts
+	History []AuditRecord
+	// Audit is called with every transfer attempt
+	Audit func(AuditRecord)
+	// OnError is called with check failures in Run. Returning nil keeps the
+	// engine running; returning an error stops it. When nil, failures are
+	// retried on the next interval.
+	OnError func(err error) error
+}
+
+// AuditRecord describes one transfer attempt and the state that caused it
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	Amount    float64   `json:"amount"`
+	// Balance, Locked and Free are the futures wallet before the transfer
+	Balance float64 `json:"balance"`
+	Locked  float64 `json:"locked"`
+	Free    float64 `json:"free"`
+	Reason  string  `json:"reason"`
+	// Capped is set when a daily cap reduced the amount
+	Capped bool `json:"capped,omitempty"`
+	// Key is the idempotency key the transfer was sent with. A retry of a
+	// transfer with an unknown outcome reuses it.
+	Key           string `json:"key,omitempty"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Success       bool   `json:"success"`
+	// Unknown is set when the error leaves open whether the funds moved,
+	// e.g. after a timeout. The amount counts toward the daily cap and the
+	// next check retries the transfer with the same key before anything else.
+	Unknown bool   `json:"unknown,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Engine evaluates the policy against the futures balance and transfers
+type Engine struct {
+	wallet Wallet
+	config Config
+
+	mu      sync.Mutex
+	history []AuditRecord
+	now     func() time.Time
+}
+
+// New validates the policy and creates an engine
+func New(wallet Wallet, config Config) (*Engine, error) {
+	p := &config.Policy
+	if p.MinBuffer < 0 || p.MaxIdle < 0 || p.DailyTopUpCap < 0 || p.DailySweepCap < 0 || p.MinTransfer < 0 {
+		return nil, fmt.Errorf("policy amounts must not be negative")
+	}
+	if p.MaxIdle > 0 && p.MaxIdle <= p.MinBuffer {
+		return nil, fmt.Errorf("max idle %v must exceed min buffer %v", p.MaxIdle, p.MinBuffer)
+	}
+	if p.Target == 0 {
+		p.Target = p.MinBuffer
+		if p.MaxIdle > 0 {
+			p.Target = (p.MinBuffer + p.MaxIdle) / 2
+		}
+	}
+	if p.Target < p.MinBuffer || (p.MaxIdle > 0 && p.Target > p.MaxIdle) {
+		return nil, fmt.Errorf("target %v must lie within the band", p.Target)
+	}
+	if p.Decimals <= 0 {
+		p.Decimals = 2
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.Location == nil {
+		config.Location = time.UTC
+	}
+
+	return &Engine{
+		wallet:  wallet,
+		config:  config,
+		history: append([]AuditRecord(nil), config.History...),
+		now:     time.Now,
+	}, nil
+}
+
+// Check reads the futures balance once and transfers if it is outside the
+// band. It returns the audit record of the transfer, or nil when no transfer
+// was needed or allowed. While the last transfer's outcome is unknown, Check
+// only retries it with the same idempotency key.
+func (e *Engine) Check() (*AuditRecord, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if n := len(e.history); n > 0 && e.history[n-1].Unknown {
+		retry := e.history[n-1]
+		retry.Time = e.now()
+		return e.send(retry)
+	}
+
+	balance, err := e.wallet.GetFuturesBalance()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get futures balance: %w", err)
+	}
+	total, err := mudrex.ParseDecimal("balance", balance.Balance)
+	if err != nil {
+		return nil, err
+	}
+	locked, err := mudrex.ParseDecimal("locked_amount", balance.LockedAmount)
+	if err != nil {
+		return nil, err
+	}
+	free := total - locked
+
+	now := e.now()
+	p := e.config.Policy
+
+	var direction Direction
+	var amount float64
+	var reason string
+	switch {
+	case free < p.MinBuffer:
+		direction, amount = TopUp, p.Target-free
+		reason = fmt.Sprintf("free balance %v below min buffer %v", free, p.MinBuffer)
+	case p.MaxIdle > 0 && free > p.MaxIdle:
+		direction, amount = Sweep, free-p.Target
+		reason = fmt.Sprintf("free balance %v above max idle %v", free, p.MaxIdle)
+	default:
+		return nil, nil
+	}
+
+	if last := e.lastTransfer(); !last.IsZero() && now.Sub(last) < p.Cooldown {
+		return nil, nil
+	}
+
+	capped := false
+	if limit := e.dailyCap(direction); limit > 0 {
+		remaining := limit - e.transferredToday(direction, now)
+		if amount > remaining {
+			amount, capped = remaining, true
+		}
+	}
+
+	scale := math.Pow(10, float64(p.Decimals))
+	amount = math.Floor(amount*scale) / scale
+	if amount <= 0 || amount < p.MinTransfer {
+		return nil, nil
+	}
+
+	key, err := mudrex.NewIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+	return e.send(AuditRecord{
+		Time:      now,
+		Direction: direction,
+		Amount:    amount,
+		Balance:   total,
+		Locked:    locked,
+		Free:      free,
+		Reason:    reason,
+		Capped:    capped,
+		Key:       key,
+	})
+}
+
+// send transfers record's amount with its key and audits the outcome
+func (e *Engine) send(record AuditRecord) (*AuditRecord, error) {
+	from, to := mudrex.WalletTypeSpot, mudrex.WalletTypeFutures
+	if record.Direction == Sweep {
+		from, to = mudrex.WalletTypeFutures, mudrex.WalletTypeSpot
+	}
+
+	record.TransactionID, record.Success, record.Unknown, record.Error = "", false, false, ""
+	amount := strconv.FormatFloat(record.Amount, 'f', e.config.Policy.Decimals, 64)
+	result, err := e.wallet.TransferWithKey(record.Key, from, to, amount)
+	switch {
+	case err != nil:
+		record.Unknown = !mudrex.IsRejected(err)
+		record.Error = err.Error()
+	case !result.Success:
+		record.TransactionID = result.TransactionID
+		record.Error = "transfer was not successful"
+	default:
+		record.TransactionID = result.TransactionID
+		record.Success = true
+	}
+
+	e.history = append(e.history, record)
+	if e.config.Audit != nil {
+		e.config.Audit(record)
+	}
+	switch {
+	case record.Unknown:
+		return &record, fmt.Errorf("%s of %v has an unknown outcome and will be retried: %s", record.Direction, record.Amount, record.Error)
+	case record.Error != "":
+		return &record, fmt.Errorf("%s of %v failed: %s", record.Direction, record.Amount, record.Error)
+	}
+	return &record, nil
+}
+
+// Run checks every interval until ctx is cancelled
+func (e *Engine) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := e.Check(); err != nil && e.config.OnError != nil {
+			if err := e.config.OnError(err); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// History returns the audit records of every transfer attempt, including
+// any seeded through Config.History
+func (e *Engine) History() []AuditRecord {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]AuditRecord(nil), e.history...)
+}
+
+func (e *Engine) lastTransfer() time.Time {
+	if len(e.history) == 0 {
+		return time.Time{}
+	}
+	return e.history[len(e.history)-1].Time
+}
+
+func (e *Engine) dailyCap(direction Direction) float64 {
+	if direction == TopUp {
+		return e.config.Policy.DailyTopUpCap
+	}
+	return e.config.Policy.DailySweepCap
+}
+
+// transferredToday sums the transfers in direction since midnight that
+// succeeded or may have. Only the latest attempt per key counts, so a
+// retried transfer is not counted twice.
+func (e *Engine) transferredToday(direction Direction, now time.Time) float64 {
+	local := now.In(e.config.Location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, e.config.Location)
+
+	latest := make(map[string]int)
+	for i, r := range e.history {
+		if r.Key != "" {
+			latest[r.Key] = i
+		}
+	}
+
+	var total float64
+	for i, r := range e.history {
+		if r.Direction != direction || r.Time.Before(midnight) || (r.Key != "" && latest[r.Key] != i) {
+			continue
+		}
+		if r.Success || r.Unknown {
+			total += r.Amount
+		}
+	}
+	return total
+}
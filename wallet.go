@@ -1,11 +1,5 @@
 package mudrex
 
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-)
-
 // WalletAPI handles wallet-related endpoints
 type WalletAPI struct {
 	client *Client
@@ -13,73 +7,42 @@ type WalletAPI struct {
 
 // GetSpotBalance retrieves the spot wallet balance
 func (w *WalletAPI) GetSpotBalance() (*WalletBalance, error) {
-	resp, err := w.client.Post("/wallet/funds", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get spot balance: %w", err)
-	}
-	
-	var apiResp APIResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-	
-	var balance WalletBalance
-	if err := json.Unmarshal(apiResp.Data, &balance); err != nil {
-		return nil, fmt.Errorf("failed to parse balance: %w", err)
-	}
-	
-	return &balance, nil
+	return doPtr[WalletBalance](w.client, request{
+		method: "POST",
+		path:   "/wallet/funds",
+		op:     "get spot balance",
+	})
 }
 
 // GetFuturesBalance retrieves the futures wallet balance
 func (w *WalletAPI) GetFuturesBalance() (*FuturesBalance, error) {
-	resp, err := w.client.Get("/futures/funds")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get futures balance: %w", err)
-	}
-	
-	var apiResp APIResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-	
-	var balance FuturesBalance
-	if err := json.Unmarshal(apiResp.Data, &balance); err != nil {
-		return nil, fmt.Errorf("failed to parse balance: %w", err)
-	}
-	
-	return &balance, nil
+	return doPtr[FuturesBalance](w.client, request{
+		method: "GET",
+		path:   "/futures/funds",
+		op:     "get futures balance",
+	})
 }
 
 // Transfer transfers funds between wallets
 func (w *WalletAPI) Transfer(fromWallet, toWallet WalletType, amount string) (*TransferResult, error) {
-	body := map[string]interface{}{
-		"from_wallet_type": fromWallet,
-		"to_wallet_type":   toWallet,
-		"amount":           amount,
-	}
-	
-	jsonBody, err := json.Marshal(body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-	
-	resp, err := w.client.Post("/wallet/transfer", bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to transfer funds: %w", err)
-	}
-	
-	var apiResp APIResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-	
-	var result TransferResult
-	if err := json.Unmarshal(apiResp.Data, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse transfer result: %w", err)
-	}
-	
-	return &result, nil
+	return w.TransferWithKey("", fromWallet, toWallet, amount)
+}
+
+// TransferWithKey transfers funds between wallets, sending key as the
+// idempotency key so a retried request is not applied twice. An empty key
+// sends none.
+func (w *WalletAPI) TransferWithKey(key string, fromWallet, toWallet WalletType, amount string) (*TransferResult, error) {
+	return doPtr[TransferResult](w.client, request{
+		method: "POST",
+		path:   "/wallet/transfer",
+		body: map[string]interface{}{
+			"from_wallet_type": fromWallet,
+			"to_wallet_type":   toWallet,
+			"amount":           amount,
+		},
+		header: idempotencyHeader(key),
+		op:     "transfer funds",
+	})
 }
 
 // TransferToFutures transfers from spot to futures wallet
@@ -0,0 +1,341 @@
+package mudrex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Event is emitted by a Watcher. Use a type switch to handle specific events.
+type Event interface {
+	EventTime() time.Time
+	isEvent()
+}
+
+type eventBase struct {
+	Time time.Time
+}
+
+func (e eventBase) EventTime() time.Time { return e.Time }
+func (eventBase) isEvent()               {}
+
+// PositionOpened is emitted when a new position appears
+type PositionOpened struct {
+	eventBase
+	Position Position
+}
+
+// PositionSizeChanged is emitted when an open position's quantity changes
+type PositionSizeChanged struct {
+	eventBase
+	Previous Position
+	Position Position
+}
+
+// PositionClosed is emitted when a position disappears with CLOSED status
+type PositionClosed struct {
+	eventBase
+	Position Position
+}
+
+// PositionLiquidated is emitted when a position disappears with LIQUIDATED status
+type PositionLiquidated struct {
+	eventBase
+	Position Position
+}
+
+// OrderFilled is emitted when an order is completely filled
+type OrderFilled struct {
+	eventBase
+	Order Order
+}
+
+// OrderPartiallyFilled is emitted when an open order's filled quantity grows
+type OrderPartiallyFilled struct {
+	eventBase
+	Previous Order
+	Order    Order
+}
+
+// OrderCancelled is emitted when an order is cancelled or expires
+type OrderCancelled struct {
+	eventBase
+	Order Order
+}
+
+// WatchError is emitted when a poll fails, or when an order or position
+// leaves the open list without a final status. The watcher keeps running and
+// keeps tracking such orders and positions until their outcome is known.
+type WatchError struct {
+	eventBase
+	Err error
+}
+
+// WatcherConfig configures a Watcher
+type WatcherConfig struct {
+	// Assets whose open orders are watched. Positions are filtered to these
+	// assets when set; otherwise all positions are watched.
+	Assets []string
+	// PositionInterval between position polls (default 5s)
+	PositionInterval time.Duration
+	// OrderInterval between order polls (default 5s)
+	OrderInterval time.Duration
+	// MaxBackoff caps the interval after repeated rate limit errors (default 1m)
+	MaxBackoff time.Duration
+	// BufferSize of the events channel (default 64)
+	BufferSize int
+}
+
+// Watcher polls positions and open orders, diffs successive snapshots and
+// emits typed events
+type Watcher struct {
+	client *Client
+	config WatcherConfig
+	assets map[string]bool
+	events chan Event
+
+	mu      sync.Mutex
+	started bool
+
+	positions map[string]Position
+	orders    map[string]Order
+}
+
+// NewWatcher creates a watcher for client
+func NewWatcher(client *Client, config WatcherConfig) *Watcher {
+	if config.PositionInterval <= 0 {
+		config.PositionInterval = 5 * time.Second
+	}
+	if config.OrderInterval <= 0 {
+		config.OrderInterval = 5 * time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Minute
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 64
+	}
+
+	assets := make(map[string]bool, len(config.Assets))
+	for _, assetID := range config.Assets {
+		assets[assetID] = true
+	}
+
+	return &Watcher{
+		client: client,
+		config: config,
+		assets: assets,
+		events: make(chan Event, config.BufferSize),
+	}
+}
+
+// Events returns the channel events are delivered on. It is closed when Run returns.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Run polls until ctx is cancelled. The first poll of each kind only records
+// the current state; events are emitted for changes after that.
+func (w *Watcher) Run(ctx context.Context) error {
+	w.mu.Lock()
+	if w.started {
+		w.mu.Unlock()
+		return fmt.Errorf("watcher already started")
+	}
+	w.started = true
+	w.mu.Unlock()
+
+	defer close(w.events)
+
+	positions := newPollSchedule(w.config.PositionInterval, w.config.MaxBackoff)
+	orders := newPollSchedule(w.config.OrderInterval, w.config.MaxBackoff)
+
+	for {
+		now := time.Now()
+		if !positions.next.After(now) {
+			positions.done(w.pollPositions(ctx))
+		}
+		if !orders.next.After(now) {
+			orders.done(w.pollOrders(ctx))
+		}
+
+		wait := time.Until(positions.next)
+		if d := time.Until(orders.next); d < wait {
+			wait = d
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+func (w *Watcher) pollPositions(ctx context.Context) error {
+	open, err := w.client.Positions.ListOpen()
+	if err != nil {
+		w.emit(ctx, &WatchError{eventBase{time.Now()}, err})
+		return err
+	}
+
+	now := time.Now()
+	current := make(map[string]Position, len(open))
+	for _, p := range open {
+		if len(w.assets) == 0 || w.assets[p.AssetID] || w.assets[p.Symbol] {
+			current[p.PositionID] = p
+		}
+	}
+
+	if w.positions == nil {
+		w.positions = current
+		return nil
+	}
+
+	for id, p := range current {
+		prev, ok := w.positions[id]
+		switch {
+		case !ok:
+			w.emit(ctx, &PositionOpened{eventBase{now}, p})
+		case prev.Quantity != p.Quantity:
+			w.emit(ctx, &PositionSizeChanged{eventBase{now}, prev, p})
+		}
+	}
+
+	var getErr error
+	for id, prev := range w.positions {
+		if _, ok := current[id]; ok {
+			continue
+		}
+		final, err := w.client.Positions.Get(id)
+		switch {
+		case err != nil:
+			// A close and a liquidation look the same from the open list,
+			// so keep tracking the position until its final status is known
+			w.emit(ctx, &WatchError{eventBase{now}, fmt.Errorf("position %s is no longer open, final status unknown: %w", id, err)})
+			current[id] = prev
+			if getErr == nil {
+				getErr = err
+			}
+		case final.Status == PositionStatusLiquidated:
+			w.emit(ctx, &PositionLiquidated{eventBase{now}, *final})
+		case final.Status == PositionStatusClosed:
+			w.emit(ctx, &PositionClosed{eventBase{now}, *final})
+		default:
+			w.emit(ctx, &WatchError{eventBase{now}, fmt.Errorf("position %s is no longer open but has status %s", id, final.Status)})
+			current[id] = prev
+		}
+	}
+
+	w.positions = current
+	return getErr
+}
+
+func (w *Watcher) pollOrders(ctx context.Context) error {
+	current := make(map[string]Order)
+	for _, assetID := range w.config.Assets {
+		open, err := w.client.Orders.ListOpen(assetID)
+		if err != nil {
+			w.emit(ctx, &WatchError{eventBase{time.Now()}, err})
+			return err
+		}
+		for _, o := range open {
+			if o.AssetID == "" {
+				o.AssetID = assetID
+			}
+			current[o.OrderID] = o
+		}
+	}
+
+	if w.orders == nil {
+		w.orders = current
+		return nil
+	}
+
+	now := time.Now()
+	for id, o := range current {
+		prev, ok := w.orders[id]
+		switch {
+		case o.Status == OrderStatusFilled:
+			if !ok || prev.Status != OrderStatusFilled {
+				w.emit(ctx, &OrderFilled{eventBase{now}, o})
+			}
+		case ok && prev.FilledQuantity != o.FilledQuantity:
+			w.emit(ctx, &OrderPartiallyFilled{eventBase{now}, prev, o})
+		case !ok && o.Status == OrderStatusPartiallyFilled:
+			w.emit(ctx, &OrderPartiallyFilled{eventBase{now}, Order{}, o})
+		}
+	}
+
+	var getErr error
+	for id, prev := range w.orders {
+		if _, ok := current[id]; ok || prev.Status == OrderStatusFilled {
+			continue
+		}
+		final, err := w.client.Orders.Get(prev.AssetID, id)
+		if err != nil {
+			// The order may have filled, so keep tracking it until its
+			// final status is known
+			w.emit(ctx, &WatchError{eventBase{now}, fmt.Errorf("order %s is no longer open, final status unknown: %w", id, err)})
+			current[id] = prev
+			if getErr == nil {
+				getErr = err
+			}
+			continue
+		}
+		switch final.Status {
+		case OrderStatusFilled:
+			w.emit(ctx, &OrderFilled{eventBase{now}, *final})
+		case OrderStatusCancelled, OrderStatusExpired:
+			w.emit(ctx, &OrderCancelled{eventBase{now}, *final})
+		default:
+			w.emit(ctx, &WatchError{eventBase{now}, fmt.Errorf("order %s is no longer open but has status %s", id, final.Status)})
+			current[id] = prev
+		}
+	}
+
+	w.orders = current
+	return getErr
+}
+
+// emit delivers an event unless ctx is cancelled first
+func (w *Watcher) emit(ctx context.Context, e Event) {
+	select {
+	case w.events <- e:
+	case <-ctx.Done():
+	}
+}
+
+// pollSchedule tracks when a poll is next due, backing off on rate limits
+type pollSchedule struct {
+	interval   time.Duration
+	maxBackoff time.Duration
+	backoff    time.Duration
+	next       time.Time
+}
+
+func newPollSchedule(interval, maxBackoff time.Duration) *pollSchedule {
+	return &pollSchedule{interval: interval, maxBackoff: maxBackoff, next: time.Now()}
+}
+
+func (s *pollSchedule) done(err error) {
+	var rateLimited *RateLimitError
+	if errors.As(err, &rateLimited) {
+		if s.backoff == 0 {
+			s.backoff = s.interval
+		}
+		s.backoff *= 2
+		if s.backoff > s.maxBackoff {
+			s.backoff = s.maxBackoff
+		}
+		s.next = time.Now().Add(s.backoff)
+		return
+	}
+
+	s.backoff = 0
+	s.next = time.Now().Add(s.interval)
+}